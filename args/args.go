@@ -0,0 +1,321 @@
+// Package args has the command line arguments shared by code generators, and
+// runs them.
+package args
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/zhaolion/gen/generator"
+	"github.com/zhaolion/gen/namer"
+	"github.com/zhaolion/gen/parser"
+	"github.com/zhaolion/gen/types"
+	"github.com/zhaolion/gen/walk"
+)
+
+// GeneratorArgs has the arguments of a generator run.
+type GeneratorArgs struct {
+	// Input directories or import paths. A trailing "/..." adds the
+	// subdirectories too.
+	InputDirs []string
+
+	// Build tags to parse the inputs with.
+	BuildTags []string
+
+	// Base directory the output packages are written below, each to
+	// OutputBase/<import path>. The default "." writes to ./<import path>,
+	// like in a GOPATH; to write next to the sources of a module, use the
+	// directory its module path is relative to, e.g. "../../.." at the root
+	// of module github.com/x/y.
+	OutputBase string
+
+	// Import path of the package to write to. If empty, the files for the
+	// types of each input package are written to that package.
+	OutputPackagePath string
+
+	// File with the header, e.g. a license boilerplate, of the generated
+	// files.
+	GoHeaderFilePath string
+
+	// Names of the generators to run. If empty, all generators are run.
+	Generators []string
+
+	// Output package import paths of some generators, by generator name,
+	// overriding OutputPackagePath.
+	GeneratorOutputs map[string]string
+
+	// Filter expressions (see walk.ParseFilter) of types not to generate
+	// for.
+	Excludes []string
+
+	// Compare the generated files to those on disk instead of writing them,
+	// and fail if any differ.
+	Verify bool
+
+	// Where Verify prints the differences. Defaults to os.Stdout.
+	DiffOutput io.Writer
+
+	// Log debug output of the parser.
+	Verbose bool
+}
+
+// Default returns the default arguments.
+func Default() *GeneratorArgs {
+	return &GeneratorArgs{OutputBase: "."}
+}
+
+// AddFlags adds flags for the arguments to fs. List flags take comma
+// separated values, and may be repeated.
+func (a *GeneratorArgs) AddFlags(fs *flag.FlagSet) {
+	fs.Var((*StringList)(&a.InputDirs), "input-dirs", "comma separated input directories or import paths; a trailing /... adds subdirectories")
+	fs.Var((*StringList)(&a.BuildTags), "build-tag", "comma separated build tags to parse the inputs with")
+	fs.StringVar(&a.OutputBase, "output-base", a.OutputBase, "base directory the output packages are written below, as <output-base>/<import path>; in a module, use the directory the module path is relative to, e.g. ../../.. for github.com/x/y")
+	fs.StringVar(&a.OutputPackagePath, "output-package", a.OutputPackagePath, "import path of the output package; by default, each input package")
+	fs.StringVar(&a.GoHeaderFilePath, "go-header-file", a.GoHeaderFilePath, "file with the header of the generated files")
+	fs.Var((*StringList)(&a.Generators), "generators", "comma separated names of the generators to run; by default, all")
+	fs.Var((*filterList)(&a.Excludes), "exclude", "filter expression of types not to generate for, e.g. \"package=example.com/x/internal/...\"; may be repeated")
+	fs.BoolVar(&a.Verify, "verify", a.Verify, "write nothing, and fail with a diff if the generated files are out of date")
+	fs.BoolVar(&a.Verbose, "v", a.Verbose, "log debug output")
+}
+
+// Validate checks the arguments.
+func (a *GeneratorArgs) Validate() error {
+	if len(a.InputDirs) == 0 {
+		return fmt.Errorf("no input directories")
+	}
+	if a.OutputBase == "" {
+		return fmt.Errorf("no output base directory")
+	}
+	for _, expr := range a.Excludes {
+		if _, err := walk.ParseFilter(expr); err != nil {
+			return fmt.Errorf("exclude: %v", err)
+		}
+	}
+	return nil
+}
+
+// NewBuilder returns a parser.Builder with the inputs added.
+func (a *GeneratorArgs) NewBuilder() (*parser.Builder, error) {
+	b := parser.New()
+	if a.Verbose {
+		b.SetDebugLevel()
+	}
+	b.AddBuildTags(a.BuildTags...)
+	for _, dir := range a.InputDirs {
+		var err error
+		if strings.HasSuffix(dir, "/...") {
+			err = b.AddDirRecursive(strings.TrimSuffix(dir, "/..."))
+		} else {
+			err = b.AddDir(dir)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("input %q: %v", dir, err)
+		}
+	}
+	return b, nil
+}
+
+// LoadGoBoilerplate returns the contents of the header file, followed by the
+// "Code generated" line marking the files as generated.
+func (a *GeneratorArgs) LoadGoBoilerplate() ([]byte, error) {
+	var header []byte
+	if a.GoHeaderFilePath != "" {
+		b, err := ioutil.ReadFile(a.GoHeaderFilePath)
+		if err != nil {
+			return nil, err
+		}
+		header = append(b, '\n')
+	}
+	return append(header, "// Code generated by gen. DO NOT EDIT.\n\n"...), nil
+}
+
+// Execute parses the inputs and runs the selected generators, given by name,
+// writing their files below OutputBase. With Verify, nothing is written; the
+// files which are out of date are printed as diffs and reported as an error.
+func (a *GeneratorArgs) Execute(generators map[string]generator.Generator) error {
+	c, packages, err := a.Prepare(generators)
+	if err != nil {
+		return err
+	}
+	if !a.Verify {
+		return c.ExecutePackages(a.OutputBase, packages...)
+	}
+	diffs, err := c.VerifyPackages(a.OutputBase, packages...)
+	if err != nil {
+		return err
+	}
+	if len(diffs) == 0 {
+		return nil
+	}
+	out := a.DiffOutput
+	if out == nil {
+		out = os.Stdout
+	}
+	for _, d := range diffs {
+		if _, err := io.WriteString(out, d.Diff); err != nil {
+			return err
+		}
+	}
+	if len(diffs) == 1 {
+		return fmt.Errorf("generated file %s is out of date", diffs[0].Path)
+	}
+	return fmt.Errorf("%d generated files are out of date", len(diffs))
+}
+
+// Prepare parses the inputs and returns the Context and the output packages
+// to run the selected generators in.
+func (a *GeneratorArgs) Prepare(generators map[string]generator.Generator) (*generator.Context, []generator.Package, error) {
+	if err := a.Validate(); err != nil {
+		return nil, nil, err
+	}
+	names, err := a.selectGenerators(generators)
+	if err != nil {
+		return nil, nil, err
+	}
+	header, err := a.LoadGoBoilerplate()
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := a.NewBuilder()
+	if err != nil {
+		return nil, nil, err
+	}
+	u, err := b.FindTypes()
+	if err != nil {
+		return nil, nil, err
+	}
+	inputs := b.FindPackages()
+	c := generator.NewContext(u, inputs...)
+
+	var excludes []*walk.Filter
+	for _, expr := range a.Excludes {
+		f, _ := walk.ParseFilter(expr)
+		excludes = append(excludes, f)
+	}
+	included := func(c *generator.Context, t *types.Type) bool {
+		for _, f := range excludes {
+			if f.Match(t) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// Group the generators by output package, "" being the input packages.
+	byOutput := map[string][]generator.Generator{}
+	var outputs []string
+	for _, name := range names {
+		out := a.GeneratorOutputs[name]
+		if out == "" {
+			out = a.OutputPackagePath
+		}
+		if _, ok := byOutput[out]; !ok {
+			outputs = append(outputs, out)
+		}
+		byOutput[out] = append(byOutput[out], generators[name])
+	}
+	sort.Strings(outputs)
+
+	var packages []generator.Package
+	for _, out := range outputs {
+		if out != "" {
+			packages = append(packages, &generator.DefaultPackage{
+				PackageName:   namer.DefaultPackageName(out),
+				PackagePath:   out,
+				HeaderText:    header,
+				GeneratorList: byOutput[out],
+				FilterFunc:    included,
+			})
+			continue
+		}
+		for _, path := range inputs {
+			p, ok := u.LookupPackage(path)
+			if !ok {
+				continue
+			}
+			packages = append(packages, &generator.DefaultPackage{
+				PackageName:   p.Name,
+				PackagePath:   path,
+				HeaderText:    header,
+				GeneratorList: byOutput[out],
+				FilterFunc:    inPackage(path, included),
+			})
+		}
+	}
+	return c, packages, nil
+}
+
+// inPackage returns a filter accepting the types of the package with the
+// given path which f accepts.
+func inPackage(path string, f func(*generator.Context, *types.Type) bool) func(*generator.Context, *types.Type) bool {
+	return func(c *generator.Context, t *types.Type) bool {
+		return t.Name.Package == path && f(c, t)
+	}
+}
+
+// selectGenerators returns the names of the generators in Generators, or of
+// all of them, sorted.
+func (a *GeneratorArgs) selectGenerators(generators map[string]generator.Generator) ([]string, error) {
+	names := a.Generators
+	if len(names) == 0 {
+		for name := range generators {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no generators")
+	}
+	for _, name := range names {
+		if _, ok := generators[name]; !ok {
+			return nil, fmt.Errorf("unknown generator %q", name)
+		}
+	}
+	return names, nil
+}
+
+// StringList is a flag.Value of comma separated strings. Repeated flags are
+// appended.
+type StringList []string
+
+// String returns the strings, comma separated.
+func (l *StringList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+// Set appends the comma separated strings s.
+func (l *StringList) Set(s string) error {
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// filterList is a flag.Value of filter expressions. These contain commas, so
+// each flag adds one.
+type filterList []string
+
+func (l *filterList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, "; ")
+}
+
+func (l *filterList) Set(s string) error {
+	if _, err := walk.ParseFilter(s); err != nil {
+		return err
+	}
+	*l = append(*l, s)
+	return nil
+}
@@ -0,0 +1,136 @@
+package args
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhaolion/gen/generator"
+	"github.com/zhaolion/gen/templates"
+)
+
+func TestAddFlags(t *testing.T) {
+	a := Default()
+	fs := flag.NewFlagSet("gen", flag.ContinueOnError)
+	a.AddFlags(fs)
+	err := fs.Parse([]string{
+		"-input-dirs", "./a, ./b/...", "-input-dirs", "./c",
+		"-build-tag", "ignore_autogenerated",
+		"-output-package", "example.com/out",
+		"-generators", "names",
+		"-exclude", "name=Bar,Baz kind=struct", "-exclude", "marker=skip",
+		"-verify",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &GeneratorArgs{
+		InputDirs:         []string{"./a", "./b/...", "./c"},
+		BuildTags:         []string{"ignore_autogenerated"},
+		OutputBase:        ".",
+		OutputPackagePath: "example.com/out",
+		Generators:        []string{"names"},
+		Excludes:          []string{"name=Bar,Baz kind=struct", "marker=skip"},
+		Verify:            true,
+	}, a)
+	assert.Error(t, fs.Parse([]string{"-exclude", "size=big"}))
+}
+
+func testGenerators(t *testing.T) map[string]generator.Generator {
+	names, err := templates.New("names", `var Names = []string{ {{range .Types}}"{{.Name.Name}}", {{end}} }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := templates.New("count", `var Count = {{len .Types}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]generator.Generator{"names": names, "count": count}
+}
+
+func TestExecute(t *testing.T) {
+	dir, err := ioutil.TempDir("", "args")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	header := filepath.Join(dir, "boilerplate.go.txt")
+	assert.NoError(t, ioutil.WriteFile(header, []byte("// Copyright The Authors.\n"), 0644))
+
+	a := Default()
+	a.InputDirs = []string{"../parser/testpkg/..."}
+	a.OutputBase = dir
+	a.GoHeaderFilePath = header
+	a.Generators = []string{"names"}
+	assert.NoError(t, a.Execute(testGenerators(t)))
+
+	got, err := ioutil.ReadFile(filepath.Join(dir, "github.com/zhaolion/gen/parser/testpkg/a2/names.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, `// Copyright The Authors.
+
+// Code generated by gen. DO NOT EDIT.
+
+package a2
+
+var Names = []string{"Embedded", "Entry"}
+`, string(got))
+	_, err = os.Stat(filepath.Join(dir, "github.com/zhaolion/gen/parser/testpkg/a2/count.go"))
+	assert.True(t, os.IsNotExist(err))
+
+	a.InputDirs = []string{"../parser/testpkg/a1", "../parser/testpkg/a2"}
+	a.OutputPackagePath = "example.com/out"
+	a.Generators = nil
+	assert.NoError(t, a.Execute(testGenerators(t)))
+	got, err = ioutil.ReadFile(filepath.Join(dir, "example.com/out/count.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(got), "package out\n\nvar Count = 4\n")
+}
+
+func TestExecuteVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "args")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	a := Default()
+	a.InputDirs = []string{"../parser/testpkg/a2"}
+	a.OutputBase = dir
+	a.DiffOutput = &out
+	a.Verify = true
+	path := filepath.Join(dir, "github.com/zhaolion/gen/parser/testpkg/a2/names.go")
+	assert.EqualError(t, a.Execute(testGenerators(t)), "2 generated files are out of date")
+	assert.Contains(t, out.String(), "--- /dev/null\n+++ "+path+" (generated)\n")
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	a.Verify = false
+	assert.NoError(t, a.Execute(testGenerators(t)))
+	a.Verify = true
+	out.Reset()
+	assert.NoError(t, a.Execute(testGenerators(t)))
+	assert.Equal(t, "", out.String())
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("package a2\n"), 0644))
+	assert.EqualError(t, a.Execute(testGenerators(t)), "generated file "+path+" is out of date")
+	assert.Contains(t, out.String(), "@@ -1 +1,5 @@\n+// Code generated by gen. DO NOT EDIT.\n+\n package a2\n")
+}
+
+func TestExecuteErrors(t *testing.T) {
+	a := Default()
+	assert.EqualError(t, a.Execute(testGenerators(t)), "no input directories")
+
+	a.InputDirs = []string{"../parser/testpkg/a1"}
+	a.Generators = []string{"deepcopy"}
+	assert.EqualError(t, a.Execute(testGenerators(t)), `unknown generator "deepcopy"`)
+
+	a.Generators = nil
+	assert.EqualError(t, a.Execute(nil), "no generators")
+
+	a.GoHeaderFilePath = "does-not-exist.txt"
+	assert.Error(t, a.Execute(testGenerators(t)))
+}
@@ -0,0 +1,97 @@
+// Command gen runs template generators over Go packages.
+//
+// Each template file is a generator named after the file, see
+// templates.ParseFile. Templates given with -templates are executed once per
+// output package, those given with -type-templates once per type:
+//
+//	gen -input-dirs ./pkg/model/... -type-templates ./hack/fields.go.tmpl \
+//		-go-header-file ./hack/boilerplate.go.txt
+//
+// Alternatively, the run is described by a configuration file (see package
+// config), and flags given on the command line override it; list flags add
+// to its lists:
+//
+//	gen -config ./hack/gen.yaml
+//
+// With -verify, nothing is written; gen prints the differences to the files
+// on disk and fails if the generated code is out of date.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/zhaolion/gen/args"
+	"github.com/zhaolion/gen/config"
+	"github.com/zhaolion/gen/generator"
+	"github.com/zhaolion/gen/templates"
+)
+
+// options are the command line flags.
+type options struct {
+	args             *args.GeneratorArgs
+	configPath       string
+	packageTemplates args.StringList
+	typeTemplates    args.StringList
+}
+
+func (o *options) parse(a *args.GeneratorArgs, arguments []string) error {
+	o.args = a
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+	a.AddFlags(fs)
+	fs.StringVar(&o.configPath, "config", o.configPath, "configuration file")
+	fs.Var(&o.packageTemplates, "templates", "comma separated template files executed once per package")
+	fs.Var(&o.typeTemplates, "type-templates", "comma separated template files executed once per type")
+	return fs.Parse(arguments)
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(arguments []string) error {
+	o := &options{}
+	if err := o.parse(args.Default(), arguments); err != nil {
+		return err
+	}
+	generators := map[string]generator.Generator{}
+	if o.configPath != "" {
+		c, err := config.Load(o.configPath)
+		if err != nil {
+			return err
+		}
+		generators = c.GeneratorMap()
+		// Parse the flags again, over the configuration.
+		o = &options{configPath: o.configPath}
+		if err := o.parse(c.Args(), arguments); err != nil {
+			return err
+		}
+	}
+	if err := loadTemplates(generators, o.packageTemplates, false); err != nil {
+		return err
+	}
+	if err := loadTemplates(generators, o.typeTemplates, true); err != nil {
+		return err
+	}
+	return o.args.Execute(generators)
+}
+
+// loadTemplates adds the template generators in the given files.
+func loadTemplates(generators map[string]generator.Generator, paths []string, perType bool) error {
+	for _, path := range paths {
+		g, err := templates.ParseFile(path)
+		if err != nil {
+			return err
+		}
+		g.PerType = perType
+		if _, ok := generators[g.Name()]; ok {
+			return fmt.Errorf("%s: generator %q defined more than once", path, g.Name())
+		}
+		generators[g.Name()] = g
+	}
+	return nil
+}
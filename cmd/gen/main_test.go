@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhaolion/gen/args"
+)
+
+// TestMain runs the command instead of the tests if GEN_TEST_MAIN is set, so
+// that the tests can check its exit codes.
+func TestMain(m *testing.M) {
+	if os.Getenv("GEN_TEST_MAIN") != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// gen runs the command with arguments, and returns its exit code and output.
+func gen(t *testing.T, arguments ...string) (int, string) {
+	cmd := exec.Command(os.Args[0], arguments...)
+	cmd.Env = append(os.Environ(), "GEN_TEST_MAIN=1")
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode(), out.String()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return 0, out.String()
+}
+
+func TestParse(t *testing.T) {
+	o := &options{}
+	err := o.parse(args.Default(), []string{
+		"-input-dirs", "./a/...",
+		"-templates", "x.go.tmpl,y.txt.tmpl",
+		"-type-templates", "z.go.tmpl",
+		"-config", "gen.yaml",
+		"-verify",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"./a/..."}, o.args.InputDirs)
+	assert.Equal(t, args.StringList{"x.go.tmpl", "y.txt.tmpl"}, o.packageTemplates)
+	assert.Equal(t, args.StringList{"z.go.tmpl"}, o.typeTemplates)
+	assert.Equal(t, "gen.yaml", o.configPath)
+	assert.True(t, o.args.Verify)
+	assert.Equal(t, ".", o.args.OutputBase)
+}
+
+func TestExitCodes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gen")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	run := []string{"-config", "../../config/testdata/gen.yaml", "-output-base", dir}
+
+	code, out := gen(t)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "gen: no input directories\n", out)
+
+	code, _ = gen(t, "-unknown")
+	assert.Equal(t, 2, code)
+
+	code, out = gen(t, append(run, "-verify")...)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out, "--- /dev/null\n")
+
+	code, out = gen(t, run...)
+	assert.Equal(t, 0, code, out)
+	_, err = os.Stat(filepath.Join(dir, "github.com/zhaolion/gen/parser/testpkg/model/zz_generated.fields.go"))
+	assert.NoError(t, err)
+
+	code, out = gen(t, append(run, "-verify")...)
+	assert.Equal(t, 0, code, out)
+	assert.Equal(t, "", out)
+
+	code, out = gen(t, "-config", "testdata/missing.yaml")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out, "gen: ")
+}
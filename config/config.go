@@ -0,0 +1,365 @@
+// Package config loads the declarative configuration of a generator run from
+// a YAML file:
+//
+//	inputs:
+//	  - ./pkg/model/...
+//	buildTags: [ignore_autogenerated]
+//	excludes:
+//	  - package=example.com/x/pkg/model/internal/...
+//	boilerplate: hack/boilerplate.go.txt
+//	output:
+//	  base: ../../..
+//	  package: example.com/x/pkg/generated
+//	generators:
+//	  - name: fields
+//	    template: hack/zz_generated.fields.go.tmpl
+//	    perType: true
+//	    filter: kind=struct marker=fields
+//	    options:
+//	      tag: json
+//
+// Relative file paths, including inputs starting with "./" or "../", are
+// relative to the directory of the configuration file.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/zhaolion/gen/args"
+	"github.com/zhaolion/gen/generator"
+	"github.com/zhaolion/gen/templates"
+	"github.com/zhaolion/gen/types"
+	"github.com/zhaolion/gen/walk"
+)
+
+// Config is the configuration of a generator run.
+type Config struct {
+	// Input directories or import paths. A trailing "/..." adds the
+	// subdirectories too.
+	Inputs []string `yaml:"inputs"`
+
+	// Build tags to parse the inputs with.
+	BuildTags []string `yaml:"buildTags"`
+
+	// Filter expressions (see walk.ParseFilter) of types not to generate
+	// for.
+	Excludes []string `yaml:"excludes"`
+
+	// File with the header of the generated files.
+	Boilerplate string `yaml:"boilerplate"`
+
+	Output Output `yaml:"output"`
+
+	Generators []Generator `yaml:"generators"`
+
+	// The directory of the configuration file.
+	dir string
+	// The generators, by name, set by validate.
+	generators map[string]generator.Generator
+}
+
+// Output says where the generated files go.
+type Output struct {
+	// Base directory the output packages are written below. Defaults to
+	// the directory of the configuration file.
+	Base string `yaml:"base"`
+
+	// Import path of the package to write to. If empty, the files for the
+	// types of each input package are written to that package.
+	Package string `yaml:"package"`
+}
+
+// Generator configures a template generator, see package templates.
+type Generator struct {
+	// The name of the generator, unique in the configuration.
+	Name string `yaml:"name"`
+
+	// The template file.
+	Template string `yaml:"template"`
+
+	// Execute the template once per type, instead of once per package.
+	PerType bool `yaml:"perType"`
+
+	// The name of the generated file. Defaults to the template file name
+	// without ".tmpl".
+	Filename string `yaml:"filename"`
+
+	// Filter expression (see walk.ParseFilter) of the types to generate
+	// for.
+	Filter string `yaml:"filter"`
+
+	// Import path of the package to write to, overriding Output.Package.
+	Output string `yaml:"output"`
+
+	// Options passed to the template as .Options.
+	Options map[string]interface{} `yaml:"options"`
+}
+
+// Error is a problem in a configuration file.
+type Error struct {
+	Filename string
+	// The line of the problem, or 0 if it is not on a particular line.
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Filename, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.Filename, e.Line, e.Msg)
+}
+
+// ErrorList is a list of problems in a configuration file.
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	msgs := make([]string, 0, len(l))
+	for _, e := range l {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Load reads and validates the configuration file at path.
+func Load(path string) (*Config, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, src)
+}
+
+// Parse decodes and validates a configuration. Unknown keys are errors. All
+// problems are reported, as an ErrorList.
+func Parse(filename string, src []byte) (*Config, error) {
+	c := &Config{dir: filepath.Dir(filename)}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(src, &doc); err != nil {
+		return nil, yamlErrors(filename, err)
+	}
+	d := yaml.NewDecoder(bytes.NewReader(src))
+	d.KnownFields(true)
+	if err := d.Decode(c); err != nil && err != io.EOF {
+		return nil, yamlErrors(filename, err)
+	}
+	v := &validator{filename: filename, doc: &doc}
+	c.validate(v)
+	if len(v.errs) > 0 {
+		return nil, v.errs
+	}
+	return c, nil
+}
+
+// yamlLine matches the line numbers in yaml error messages.
+var yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): `)
+
+// yamlErrors converts the errors of yaml to an ErrorList.
+func yamlErrors(filename string, err error) error {
+	var msgs []string
+	if te, ok := err.(*yaml.TypeError); ok {
+		msgs = te.Errors
+	} else {
+		msgs = []string{err.Error()}
+	}
+	var errs ErrorList
+	for _, msg := range msgs {
+		e := &Error{Filename: filename, Msg: strings.TrimPrefix(msg, "yaml: ")}
+		if m := yamlLine.FindStringSubmatch(msg); m != nil {
+			e.Line, _ = strconv.Atoi(m[1])
+			e.Msg = msg[len(m[0]):]
+		}
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+type validator struct {
+	filename string
+	// The document, for the lines of the errors.
+	doc  *yaml.Node
+	errs ErrorList
+}
+
+// errorf records a problem with the value at path.
+func (v *validator) errorf(path []interface{}, format string, args ...interface{}) {
+	v.errs = append(v.errs, &Error{
+		Filename: v.filename,
+		Line:     lineOf(v.doc, path...),
+		Msg:      fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *Config) validate(v *validator) {
+	if len(c.Inputs) == 0 {
+		v.errorf([]interface{}{"inputs"}, "inputs: at least one input is required")
+	}
+	for i, in := range c.Inputs {
+		if strings.TrimSpace(in) == "" {
+			v.errorf([]interface{}{"inputs", i}, "inputs: empty input")
+		}
+	}
+	for i, expr := range c.Excludes {
+		if _, err := walk.ParseFilter(expr); err != nil {
+			v.errorf([]interface{}{"excludes", i}, "excludes: %v", err)
+		}
+	}
+	if c.Boilerplate != "" {
+		if _, err := os.Stat(c.path(c.Boilerplate)); err != nil {
+			v.errorf([]interface{}{"boilerplate"}, "boilerplate: %v", err)
+		}
+	}
+
+	if len(c.Generators) == 0 {
+		v.errorf([]interface{}{"generators"}, "generators: at least one generator is required")
+	}
+	c.generators = map[string]generator.Generator{}
+	for i := range c.Generators {
+		if g := c.Generators[i].build(c, v, []interface{}{"generators", i}); g != nil {
+			c.generators[c.Generators[i].Name] = g
+		}
+	}
+}
+
+// build validates the generator configuration, and returns the generator if
+// it is valid.
+func (gc *Generator) build(c *Config, v *validator, path []interface{}) generator.Generator {
+	at := func(key string) []interface{} {
+		return append(append([]interface{}{}, path...), key)
+	}
+	valid := true
+	errorf := func(key, format string, args ...interface{}) {
+		v.errorf(at(key), format, args...)
+		valid = false
+	}
+
+	switch {
+	case gc.Name == "":
+		errorf("name", "generator: name is required")
+	case c.duplicate(gc):
+		errorf("name", "generator %q: defined more than once", gc.Name)
+	}
+	if strings.ContainsAny(gc.Filename, `/\`) {
+		errorf("filename", "generator %q: filename %q must not contain a directory", gc.Name, gc.Filename)
+	}
+	var filter *walk.Filter
+	if gc.Filter != "" {
+		var err error
+		if filter, err = walk.ParseFilter(gc.Filter); err != nil {
+			errorf("filter", "generator %q: filter: %v", gc.Name, err)
+		}
+	}
+	if gc.Template == "" {
+		errorf("template", "generator %q: template is required", gc.Name)
+		return nil
+	}
+	g, err := templates.ParseFile(c.path(gc.Template))
+	if err != nil {
+		errorf("template", "generator %q: %v", gc.Name, err)
+		return nil
+	}
+	if !valid {
+		return nil
+	}
+
+	g.GeneratorName = gc.Name
+	g.PerType = gc.PerType
+	g.Options = gc.Options
+	if gc.Filename != "" {
+		g.OutputFilename = gc.Filename
+	}
+	if filter != nil {
+		g.TypeFilter = func(_ *generator.Context, t *types.Type) bool { return filter.Match(t) }
+	}
+	return g
+}
+
+// duplicate returns true if a generator before gc has its name.
+func (c *Config) duplicate(gc *Generator) bool {
+	for i := range c.Generators {
+		if &c.Generators[i] == gc {
+			return false
+		}
+		if c.Generators[i].Name == gc.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// path resolves a file path relative to the configuration file.
+func (c *Config) path(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(c.dir, p)
+}
+
+// input resolves an input relative to the configuration file, if it is a
+// relative directory. Import paths are left alone.
+func (c *Config) input(in string) string {
+	if !strings.HasPrefix(in, "./") && !strings.HasPrefix(in, "../") && in != "." && in != ".." {
+		return in
+	}
+	recursive := strings.HasSuffix(in, "/...")
+	p := filepath.ToSlash(filepath.Join(c.dir, strings.TrimSuffix(in, "/...")))
+	if !filepath.IsAbs(p) && !strings.HasPrefix(p, "../") && p != "." && p != ".." {
+		p = "./" + p
+	}
+	if recursive {
+		p += "/..."
+	}
+	return p
+}
+
+// Args returns the arguments of the run.
+func (c *Config) Args() *args.GeneratorArgs {
+	a := args.Default()
+	a.OutputBase = c.dir
+	if c.Output.Base != "" {
+		a.OutputBase = c.path(c.Output.Base)
+	}
+	a.OutputPackagePath = c.Output.Package
+	for _, in := range c.Inputs {
+		a.InputDirs = append(a.InputDirs, c.input(in))
+	}
+	a.BuildTags = append(a.BuildTags, c.BuildTags...)
+	a.Excludes = append(a.Excludes, c.Excludes...)
+	if c.Boilerplate != "" {
+		a.GoHeaderFilePath = c.path(c.Boilerplate)
+	}
+	for _, g := range c.Generators {
+		if g.Output == "" {
+			continue
+		}
+		if a.GeneratorOutputs == nil {
+			a.GeneratorOutputs = map[string]string{}
+		}
+		a.GeneratorOutputs[g.Name] = g.Output
+	}
+	return a
+}
+
+// GeneratorMap returns the configured generators, by name.
+func (c *Config) GeneratorMap() map[string]generator.Generator {
+	result := make(map[string]generator.Generator, len(c.generators))
+	for name, g := range c.generators {
+		result[name] = g
+	}
+	return result
+}
+
+// Execute runs the configured generators.
+func (c *Config) Execute() error {
+	return c.Args().Execute(c.GeneratorMap())
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestLoad(t *testing.T) {
+	c, err := Load("testdata/gen.yaml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	a := c.Args()
+	assert.Equal(t, []string{"../parser/testpkg/model"}, a.InputDirs)
+	assert.Equal(t, []string{"ignore_autogenerated"}, a.BuildTags)
+	assert.Equal(t, []string{"name=Bar"}, a.Excludes)
+	assert.Equal(t, filepath.Join("testdata", "out"), a.OutputBase)
+	assert.Equal(t, "", a.OutputPackagePath)
+	assert.Equal(t, filepath.Join("testdata", "boilerplate.go.txt"), a.GoHeaderFilePath)
+	assert.Equal(t, map[string]string{"names": "example.com/out"}, a.GeneratorOutputs)
+
+	generators := c.GeneratorMap()
+	assert.Len(t, generators, 2)
+	assert.Equal(t, "zz_generated.fields.go", generators["fields"].Filename())
+	assert.Equal(t, "zz_generated.names.go", generators["names"].Filename())
+}
+
+func TestInput(t *testing.T) {
+	c := &Config{dir: "hack"}
+	assert.Equal(t, "./hack/pkg/model/...", c.input("./pkg/model/..."))
+	assert.Equal(t, "./hack/...", c.input("./..."))
+	assert.Equal(t, "./hack/pkg/model", c.input("./pkg/model"))
+	assert.Equal(t, "../pkg/...", c.input("../../pkg/..."))
+	assert.Equal(t, "example.com/pkg/...", c.input("example.com/pkg/..."))
+
+	c.dir = "."
+	assert.Equal(t, "./...", c.input("./..."))
+	assert.Equal(t, ".", c.input("."))
+	assert.Equal(t, "..", c.input(".."))
+}
+
+func TestExecute(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	c, err := Load("testdata/gen.yaml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	a := c.Args()
+	a.OutputBase = dir
+	assert.NoError(t, a.Execute(c.GeneratorMap()))
+
+	got, err := ioutil.ReadFile(filepath.Join(dir, "github.com/zhaolion/gen/parser/testpkg/model/zz_generated.fields.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, `// Copyright The Authors.
+
+// Code generated by gen. DO NOT EDIT.
+
+package model
+
+var fooFields = []string{"tag"}
+`, string(got))
+
+	got, err = ioutil.ReadFile(filepath.Join(dir, "example.com/out/zz_generated.names.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(got), `var Names = []string{"Color", "Describer", "Foo", "Namer"}`)
+}
+
+func TestParseErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		src  string
+		want string
+	}{
+		"unknown key": {
+			src: `inputs: [./a]
+generator:
+  - name: x
+`,
+			want: "testdata/bad.yaml:2: field generator not found in type config.Config",
+		},
+		"wrong type": {
+			src: `inputs: [./a]
+generators:
+  - name: x
+    perType: often
+`,
+			want: "testdata/bad.yaml:4: cannot unmarshal !!str `often` into bool",
+		},
+		"syntax": {
+			src:  "inputs: [./a\n",
+			want: "testdata/bad.yaml:1: did not find expected ',' or ']'",
+		},
+		"semantic": {
+			src: `# nothing to parse
+buildTags:
+- a
+
+excludes:
+  - name=Foo
+  - bogus
+generators:
+  - name: fields
+    template: zz_generated.fields.go.tmpl
+  - name: fields
+    template: names.go.tmpl
+    filter: kind=struct
+  - template: missing.go.tmpl
+    filename: dir/x.go
+    # the filter
+    filter: "size=big"
+  -
+    name: empty
+`,
+			want: `testdata/bad.yaml: inputs: at least one input is required
+testdata/bad.yaml:7: excludes: invalid filter term "bogus": want field=pattern
+testdata/bad.yaml:11: generator "fields": defined more than once
+testdata/bad.yaml:14: generator: name is required
+testdata/bad.yaml:15: generator "": filename "dir/x.go" must not contain a directory
+testdata/bad.yaml:17: generator "": filter: invalid filter term "size=big": unknown field "size"
+testdata/bad.yaml:14: generator "": open testdata/missing.go.tmpl: no such file or directory
+testdata/bad.yaml:19: generator "empty": template is required`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse("testdata/bad.yaml", []byte(tc.src))
+			assert.EqualError(t, err, tc.want)
+		})
+	}
+}
+
+func TestLineOf(t *testing.T) {
+	var doc yaml.Node
+	err := yaml.Unmarshal([]byte(`a:
+  b: 1
+  c:
+    - x
+    - y: 2
+      z: |
+        text
+        more: text
+      w: 3
+d: [1,
+  2]
+e:
+- f: 4
+"quoted key": {g: 5,
+  h: 6}
+base: &base
+  i: 7
+alias: *base
+`), &doc)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for want, path := range map[int][]interface{}{
+		0:  {"missing"},
+		1:  {"a"},
+		2:  {"a", "b"},
+		4:  {"a", "c", 0},
+		5:  {"a", "c", 1, "y"},
+		6:  {"a", "c", 1, "z"},
+		9:  {"a", "c", 1, "w"},
+		11: {"d", 1},
+		13: {"e", 0, "f"},
+		14: {"quoted key", "g"},
+		15: {"quoted key", "h"},
+		17: {"alias", "i"},
+		18: {"alias", "missing"},
+	} {
+		assert.Equal(t, want, lineOf(&doc, path...), "%v", path)
+	}
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// lineOf returns the line of the value at path in the document n, made of
+// mapping keys and sequence indexes: the line of the key of a mapping value,
+// and of the item itself in a sequence. If it can't be found, the line of
+// its deepest parent is returned, or 0 for the document.
+func lineOf(n *yaml.Node, path ...interface{}) int {
+	if n == nil {
+		return 0
+	}
+	if n.Kind == yaml.DocumentNode {
+		if len(n.Content) == 0 {
+			return 0
+		}
+		n = n.Content[0]
+	}
+	line := 0
+	for _, p := range path {
+		for n.Kind == yaml.AliasNode && n.Alias != nil {
+			n = n.Alias
+		}
+		var key, next *yaml.Node
+		switch p := p.(type) {
+		case string:
+			key, next = mappingValue(n, p)
+		case int:
+			if n.Kind == yaml.SequenceNode && p >= 0 && p < len(n.Content) {
+				next = n.Content[p]
+			}
+		}
+		if next == nil {
+			return line
+		}
+		line = next.Line
+		if key != nil {
+			line = key.Line
+		}
+		n = next
+	}
+	return line
+}
+
+// mappingValue returns the key and value nodes of key in the mapping n, or
+// nils. Merged mappings ("<<") are not looked into.
+func mappingValue(n *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if n.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if k := n.Content[i]; k.Kind == yaml.ScalarNode && k.Value == key {
+			return k, n.Content[i+1]
+		}
+	}
+	return nil, nil
+}
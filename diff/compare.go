@@ -0,0 +1,297 @@
+package diff
+
+import (
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/zhaolion/gen/types"
+)
+
+// Compare returns the differences in the exported API of the given packages
+// between old and new. If no packages are given, all packages loaded from
+// source (i.e. having Files) in either Universe are compared. Types are
+// matched by their fully qualified names.
+func Compare(old, new types.Universe, packagePaths ...string) *Report {
+	if len(packagePaths) == 0 {
+		packagePaths = sourcePackages(old, new)
+	}
+
+	r := &Report{}
+	for _, path := range packagePaths {
+		o, inOld := old[path]
+		n, inNew := new[path]
+		switch {
+		case !inOld && !inNew:
+			continue
+		case !inOld:
+			r.add(Added, "package", path, false, "package added")
+		case !inNew:
+			r.add(Removed, "package", path, true, "package removed")
+		default:
+			comparePackage(r, o, n)
+		}
+	}
+	r.sort()
+	return r
+}
+
+func sourcePackages(us ...types.Universe) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, u := range us {
+		for path, p := range u {
+			if len(p.Files) > 0 && !seen[path] {
+				seen[path] = true
+				result = append(result, path)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func comparePackage(r *Report, o, n *types.Package) {
+	for _, name := range exportedNames(o.Types, n.Types) {
+		ot, nt := o.Types[name], n.Types[name]
+		object := o.Path + "." + name
+		switch {
+		case nt == nil:
+			r.add(Removed, "type", object, true, "type removed")
+		case ot == nil:
+			r.add(Added, "type", object, false, "type added")
+		default:
+			compareType(r, object, ot, nt)
+		}
+	}
+	for _, name := range exportedNames(o.Functions, n.Functions) {
+		ot, nt := o.Functions[name], n.Functions[name]
+		object := o.Path + "." + name
+		switch {
+		case nt == nil:
+			r.add(Removed, "function", object, true, "function removed")
+		case ot == nil:
+			r.add(Added, "function", object, false, "function added")
+		case !sameType(ot.Underlying, nt.Underlying):
+			r.add(Changed, "function", object, true, "signature changed from %s to %s", typeString(ot.Underlying), typeString(nt.Underlying))
+		}
+	}
+	for _, decl := range []struct {
+		what     string
+		old, new map[string]*types.Type
+	}{
+		{"variable", o.Variables, n.Variables},
+		{"constant", o.Constants, n.Constants},
+	} {
+		what := decl.what
+		for _, name := range exportedNames(decl.old, decl.new) {
+			ot, nt := decl.old[name], decl.new[name]
+			object := o.Path + "." + name
+			switch {
+			case nt == nil:
+				r.add(Removed, what, object, true, "%s removed", what)
+			case ot == nil:
+				r.add(Added, what, object, false, "%s added", what)
+			case !sameType(ot.Underlying, nt.Underlying):
+				r.add(Changed, what, object, true, "type changed from %s to %s", typeString(ot.Underlying), typeString(nt.Underlying))
+			}
+		}
+	}
+}
+
+func compareType(r *Report, object string, o, n *types.Type) {
+	if o.Kind != n.Kind {
+		r.add(Changed, "type", object, true, "kind changed from %s to %s", o.Kind, n.Kind)
+		return
+	}
+	switch o.Kind {
+	case types.Struct:
+		compareFields(r, object, o, n)
+		if o.IsComparable() && !n.IsComparable() {
+			r.add(Changed, "type", object, true, "no longer comparable")
+		}
+	case types.Interface:
+		compareInterface(r, object, o, n)
+		return
+	case types.Alias:
+		if !sameType(o.Underlying, n.Underlying) {
+			r.add(Changed, "type", object, true, "underlying type changed from %s to %s", typeString(o.Underlying), typeString(n.Underlying))
+		}
+	}
+	compareMethods(r, object, o, n)
+}
+
+func compareFields(r *Report, object string, o, n *types.Type) {
+	oldFields, newFields := members(o), members(n)
+	names := map[string]bool{}
+	for name := range oldFields {
+		names[name] = true
+	}
+	for name := range newFields {
+		names[name] = true
+	}
+	for _, name := range exported(names) {
+		of, nf := oldFields[name], newFields[name]
+		field := object + "." + name
+		switch {
+		case nf == nil:
+			r.add(Removed, "field", field, true, "field removed")
+		case of == nil:
+			r.add(Added, "field", field, false, "field added")
+		case !sameType(of.Type, nf.Type):
+			r.add(Changed, "field", field, true, "type changed from %s to %s", typeString(of.Type), typeString(nf.Type))
+		case of.Embedded != nf.Embedded:
+			r.add(Changed, "field", field, true, "embedding changed")
+		case of.Tags != nf.Tags:
+			r.add(Changed, "field", field, false, "tags changed from `%s` to `%s`", of.Tags, nf.Tags)
+		}
+	}
+}
+
+func compareInterface(r *Report, object string, o, n *types.Type) {
+	// Interfaces with unexported methods can't be implemented outside of
+	// their package, so adding methods to them doesn't break anybody.
+	sealed := false
+	for name := range o.Methods {
+		if !token.IsExported(name) {
+			sealed = true
+		}
+	}
+	for _, name := range exportedNames(o.Methods, n.Methods) {
+		om, nm := o.Methods[name], n.Methods[name]
+		method := object + "." + name
+		switch {
+		case nm == nil:
+			r.add(Removed, "method", method, true, "method removed")
+		case om == nil:
+			r.add(Added, "method", method, !sealed, "method added")
+		case !sameSignature(om.Signature, nm.Signature):
+			r.add(Changed, "method", method, true, "signature changed from %s to %s", signatureString(om.Signature), signatureString(nm.Signature))
+		}
+	}
+}
+
+func compareMethods(r *Report, object string, o, n *types.Type) {
+	for _, name := range exportedNames(o.Methods, n.Methods) {
+		om, nm := o.Methods[name], n.Methods[name]
+		method := object + "." + name
+		switch {
+		case nm == nil:
+			r.add(Removed, "method", method, true, "method removed")
+		case om == nil:
+			r.add(Added, "method", method, false, "method added")
+		case !sameSignature(om.Signature, nm.Signature):
+			r.add(Changed, "method", method, true, "signature changed from %s to %s", signatureString(om.Signature), signatureString(nm.Signature))
+		case !pointerReceiver(om) && pointerReceiver(nm):
+			r.add(Changed, "method", method, true, "receiver changed to pointer, no longer in the method set of the value")
+		case pointerReceiver(om) && !pointerReceiver(nm):
+			r.add(Changed, "method", method, false, "receiver changed to value")
+		}
+	}
+}
+
+func pointerReceiver(m *types.Type) bool {
+	return m.Signature != nil && m.Signature.PointerReceiver
+}
+
+func members(t *types.Type) map[string]*types.Member {
+	result := map[string]*types.Member{}
+	for i := range t.Members {
+		result[t.Members[i].Name] = &t.Members[i]
+	}
+	return result
+}
+
+// exportedNames returns the exported names in either map, sorted.
+func exportedNames(o, n map[string]*types.Type) []string {
+	names := map[string]bool{}
+	for name := range o {
+		names[name] = true
+	}
+	for name := range n {
+		names[name] = true
+	}
+	return exported(names)
+}
+
+func exported(names map[string]bool) []string {
+	result := make([]string, 0, len(names))
+	for name := range names {
+		if token.IsExported(name) {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// sameType compares types from different Universes by name. Func types are
+// compared structurally, since their names include parameter names.
+func sameType(a, b *types.Type) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Kind == types.Func && b.Kind == types.Func {
+		return sameSignature(a.Signature, b.Signature)
+	}
+	return a.Name.String() == b.Name.String()
+}
+
+func sameSignature(a, b *types.Signature) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Variadic != b.Variadic || len(a.Parameters) != len(b.Parameters) || len(a.Results) != len(b.Results) {
+		return false
+	}
+	for i := range a.Parameters {
+		if !sameType(a.Parameters[i], b.Parameters[i]) {
+			return false
+		}
+	}
+	for i := range a.Results {
+		if !sameType(a.Results[i], b.Results[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func typeString(t *types.Type) string {
+	if t == nil {
+		return "<nil>"
+	}
+	if t.Kind == types.Func && t.Signature != nil {
+		return signatureString(t.Signature)
+	}
+	return t.Name.String()
+}
+
+// signatureString formats a signature without parameter names, e.g.
+// "func(context.Context) (*model.Foo, error)".
+func signatureString(s *types.Signature) string {
+	if s == nil {
+		return "<nil>"
+	}
+	params := make([]string, 0, len(s.Parameters))
+	for i, p := range s.Parameters {
+		if s.Variadic && i == len(s.Parameters)-1 && p.Kind == types.Slice && p.Elem != nil {
+			params = append(params, "..."+typeString(p.Elem))
+			continue
+		}
+		params = append(params, typeString(p))
+	}
+	results := make([]string, 0, len(s.Results))
+	for _, r := range s.Results {
+		results = append(results, typeString(r))
+	}
+	out := "func(" + strings.Join(params, ", ") + ")"
+	switch len(results) {
+	case 0:
+	case 1:
+		out += " " + results[0]
+	default:
+		out += " (" + strings.Join(results, ", ") + ")"
+	}
+	return out
+}
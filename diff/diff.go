@@ -0,0 +1,101 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ChangeKind says how an API object changed.
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// Change is a single difference between two versions of an API.
+type Change struct {
+	Kind ChangeKind
+
+	// What changed: "package", "type", "field", "method", "function",
+	// "variable" or "constant".
+	What string
+
+	// The fully qualified name of the object, e.g.
+	// "github.com/x/model.Foo.Name".
+	Object string
+
+	// Human readable description of the change.
+	Message string
+
+	// True if the change may break code using the old API, following the Go
+	// 1 compatibility rules.
+	Breaking bool
+}
+
+// String returns the change as a single line.
+func (c Change) String() string {
+	class := "compatible"
+	if c.Breaking {
+		class = "breaking"
+	}
+	return fmt.Sprintf("%s: %s %s %s: %s", class, c.Kind, c.What, c.Object, c.Message)
+}
+
+// Report is the result of comparing two Universes.
+type Report struct {
+	// All changes, sorted by object.
+	Changes []Change
+}
+
+// Breaking returns the breaking changes.
+func (r *Report) Breaking() []Change {
+	return r.filter(true)
+}
+
+// Compatible returns the compatible changes.
+func (r *Report) Compatible() []Change {
+	return r.filter(false)
+}
+
+// HasBreaking returns true if there is at least one breaking change.
+func (r *Report) HasBreaking() bool {
+	return len(r.Breaking()) > 0
+}
+
+// String returns the changes, one per line.
+func (r *Report) String() string {
+	lines := make([]string, 0, len(r.Changes))
+	for _, c := range r.Changes {
+		lines = append(lines, c.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *Report) filter(breaking bool) []Change {
+	var result []Change
+	for _, c := range r.Changes {
+		if c.Breaking == breaking {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func (r *Report) add(kind ChangeKind, what, object string, breaking bool, format string, args ...interface{}) {
+	r.Changes = append(r.Changes, Change{
+		Kind:     kind,
+		What:     what,
+		Object:   object,
+		Message:  fmt.Sprintf(format, args...),
+		Breaking: breaking,
+	})
+}
+
+func (r *Report) sort() {
+	sort.SliceStable(r.Changes, func(i, j int) bool {
+		return r.Changes[i].Object < r.Changes[j].Object
+	})
+}
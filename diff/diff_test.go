@@ -0,0 +1,112 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaolion/gen/types"
+)
+
+const pkg = "github.com/x/model"
+
+// universe builds a small API: a struct Foo with a method, an interface
+// Namer, a function New and a constant Max.
+func universe(change func(u types.Universe)) types.Universe {
+	u := types.Universe{}
+	p := u.Package(pkg)
+	p.Name = "model"
+
+	foo := u.Type(types.Name{Package: pkg, Name: "Foo"})
+	foo.Kind = types.Struct
+	foo.Members = []types.Member{
+		{Name: "Name", Type: types.String, Tags: `json:"name"`},
+		{Name: "Age", Type: types.Int},
+		{Name: "secret", Type: types.String},
+	}
+	foo.Methods = map[string]*types.Type{
+		"Hello": {Kind: types.Func, Signature: &types.Signature{Receiver: foo, MethodName: "Hello", Results: []*types.Type{types.String}}},
+	}
+
+	namer := u.Type(types.Name{Package: pkg, Name: "Namer"})
+	namer.Kind = types.Interface
+	namer.Methods = map[string]*types.Type{
+		"Name": {Kind: types.Func, Signature: &types.Signature{Receiver: namer, MethodName: "Name", Results: []*types.Type{types.String}}},
+	}
+
+	fooPtr := u.Type(types.Name{Name: "*" + pkg + ".Foo"})
+	fooPtr.Kind = types.Pointer
+	fooPtr.Elem = foo
+	newFunc := u.Type(types.Name{Name: "func(name string) *" + pkg + ".Foo"})
+	newFunc.Kind = types.Func
+	newFunc.Signature = &types.Signature{Parameters: []*types.Type{types.String}, Results: []*types.Type{fooPtr}}
+	u.Function(types.Name{Package: pkg, Name: "New"}).Underlying = newFunc
+	u.Constant(types.Name{Package: pkg, Name: "Max"}).Underlying = types.Int
+
+	if change != nil {
+		change(u)
+	}
+	return u
+}
+
+func TestCompareUnchanged(t *testing.T) {
+	r := Compare(universe(nil), universe(nil), pkg)
+	assert.Empty(t, r.Changes)
+	assert.False(t, r.HasBreaking())
+}
+
+func TestCompare(t *testing.T) {
+	r := Compare(universe(nil), universe(func(u types.Universe) {
+		p := u.Package(pkg)
+		foo := p.Type("Foo")
+		// Name: tags changed, Age: type changed, Email: added, secret: removed.
+		foo.Members = []types.Member{
+			{Name: "Name", Type: types.String, Tags: `json:"full_name"`},
+			{Name: "Age", Type: types.Int64},
+			{Name: "Email", Type: types.String},
+		}
+		foo.Methods["Hello"].Signature.PointerReceiver = true
+		foo.Methods["Bye"] = &types.Type{Kind: types.Func, Signature: &types.Signature{Receiver: foo, MethodName: "Bye"}}
+
+		namer := p.Type("Namer")
+		namer.Methods["Describe"] = &types.Type{Kind: types.Func, Signature: &types.Signature{Receiver: namer, MethodName: "Describe"}}
+
+		delete(p.Constants, "Max")
+		u.Variable(types.Name{Package: pkg, Name: "Default"}).Underlying = types.String
+	}), pkg)
+
+	assert.Equal(t, `compatible: added variable github.com/x/model.Default: variable added
+compatible: added method github.com/x/model.Foo.Bye: method added
+compatible: added field github.com/x/model.Foo.Email: field added
+compatible: changed field github.com/x/model.Foo.Name: tags changed from `+"`json:\"name\"` to `json:\"full_name\"`"+`
+breaking: changed field github.com/x/model.Foo.Age: type changed from int to int64
+breaking: changed method github.com/x/model.Foo.Hello: receiver changed to pointer, no longer in the method set of the value
+breaking: removed constant github.com/x/model.Max: constant removed
+breaking: added method github.com/x/model.Namer.Describe: method added`, sortedString(r))
+	assert.True(t, r.HasBreaking())
+	assert.Len(t, r.Breaking(), 4)
+	assert.Len(t, r.Compatible(), 4)
+}
+
+func TestCompareSignatures(t *testing.T) {
+	r := Compare(universe(nil), universe(func(u types.Universe) {
+		p := u.Package(pkg)
+		newFunc := p.Function("New").Underlying
+		newFunc.Signature.Parameters = append(newFunc.Signature.Parameters, &types.Type{Name: types.Name{Name: "[]string"}, Kind: types.Slice, Elem: types.String})
+		newFunc.Signature.Variadic = true
+		p.Type("Namer").Kind = types.Struct
+		p.Type("Foo").Members = append(p.Type("Foo").Members, types.Member{Name: "Tags", Type: &types.Type{Name: types.Name{Name: "[]string"}, Kind: types.Slice}})
+	}), pkg)
+
+	assert.Equal(t, `breaking: changed type github.com/x/model.Foo: no longer comparable
+breaking: changed type github.com/x/model.Namer: kind changed from Interface to Struct
+breaking: changed function github.com/x/model.New: signature changed from func(string) *github.com/x/model.Foo to func(string, ...string) *github.com/x/model.Foo`, sortedString(&Report{Changes: r.Breaking()}))
+
+	r = Compare(universe(nil), types.Universe{}, pkg)
+	assert.Equal(t, "breaking: removed package github.com/x/model: package removed", r.String())
+}
+
+// sortedString lists compatible changes first, each group in report order.
+func sortedString(r *Report) string {
+	sorted := &Report{Changes: append(r.Compatible(), r.Breaking()...)}
+	return sorted.String()
+}
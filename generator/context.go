@@ -0,0 +1,101 @@
+package generator
+
+import (
+	"github.com/zhaolion/gen/namer"
+	"github.com/zhaolion/gen/types"
+)
+
+// Context is the global state of a generator run.
+type Context struct {
+	// The Universe to generate from.
+	Universe types.Universe
+
+	// The input package paths.
+	Inputs []string
+
+	// Name systems available to all generators.
+	Namers namer.NameSystems
+
+	// File types by name, see Generator.FileType.
+	FileTypes map[string]FileType
+
+	// The types to generate for: all named types of the input packages,
+	// sorted by name.
+	Order []*types.Type
+
+	// The package being rendered, set by Render.
+	Package Package
+
+	// State of the generators for the package being rendered, see State.
+	state map[interface{}]interface{}
+}
+
+// State returns the value stored with SetState for key, or nil.
+func (c *Context) State(key interface{}) interface{} {
+	return c.state[key]
+}
+
+// SetState stores the state of a generator for the package being rendered,
+// under a comparable key, usually the generator itself. Generators are
+// shared between packages, so state kept for a file in Init belongs here
+// rather than in the generator.
+func (c *Context) SetState(key, value interface{}) {
+	if c.state == nil {
+		c.state = map[interface{}]interface{}{}
+	}
+	c.state[key] = value
+}
+
+// NewContext returns a Context for generating from the given packages of u.
+func NewContext(u types.Universe, inputs ...string) *Context {
+	c := &Context{
+		Universe: u,
+		Inputs:   inputs,
+		Namers:   namer.NameSystems{},
+		FileTypes: map[string]FileType{
+			GolangFileType: golangFileType{},
+			TextFileType:   textFileType{},
+		},
+	}
+	for _, path := range inputs {
+		p, ok := u.LookupPackage(path)
+		if !ok {
+			continue
+		}
+		for _, t := range p.SortedTypes() {
+			if !t.IsMethod() {
+				c.Order = append(c.Order, t)
+			}
+		}
+	}
+	c.Order = types.SortByName(c.Order)
+	return c
+}
+
+// filterOrder returns a copy of c with the types of Order which f accepts.
+func (c *Context) filterOrder(f func(*Context, *types.Type) bool) *Context {
+	out := *c
+	out.Order = nil
+	for _, t := range c.Order {
+		if f(c, t) {
+			out.Order = append(out.Order, t)
+		}
+	}
+	return &out
+}
+
+// withNamers returns a copy of c with the given name systems added.
+func (c *Context) withNamers(ns namer.NameSystems) *Context {
+	if len(ns) == 0 {
+		return c
+	}
+	out := *c
+	out.Namers = namer.NameSystems{}
+	for name, n := range c.Namers {
+		out.Namers[name] = n
+	}
+	for name, n := range ns {
+		out.Namers[name] = n
+	}
+	return &out
+}
@@ -0,0 +1,111 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Render runs the generators of p over the types of c.Order accepted by p,
+// and returns the contents of the files they produce, by file name. If a
+// file can't be formatted, its unformatted contents are returned along with
+// the error.
+func (c *Context) Render(p Package) (map[string][]byte, error) {
+	pc := c.filterOrder(p.Filter)
+	pc.Package = p
+	pc.state = map[interface{}]interface{}{}
+
+	files := map[string]*File{}
+	var names []string
+	for _, g := range p.Generators(pc) {
+		gc := pc.withNamers(g.Namers(pc)).filterOrder(g.Filter)
+
+		f, ok := files[g.Filename()]
+		if !ok {
+			f = &File{
+				Name:        g.Filename(),
+				FileType:    g.FileType(),
+				PackageName: p.Name(),
+				PackagePath: p.Path(),
+				Header:      p.Header(g.Filename()),
+				Imports:     map[string]struct{}{},
+			}
+			files[f.Name] = f
+			names = append(names, f.Name)
+		} else if f.FileType != g.FileType() {
+			return nil, fmt.Errorf("generator %q: file %q has type %q, not %q", g.Name(), f.Name, f.FileType, g.FileType())
+		}
+
+		if err := g.Init(gc, &f.Body); err != nil {
+			return nil, fmt.Errorf("generator %q: init: %v", g.Name(), err)
+		}
+		for _, t := range gc.Order {
+			if err := g.GenerateType(gc, t, &f.Body); err != nil {
+				return nil, fmt.Errorf("generator %q: type %s: %v", g.Name(), t, err)
+			}
+		}
+		if err := g.Finalize(gc, &f.Body); err != nil {
+			return nil, fmt.Errorf("generator %q: finalize: %v", g.Name(), err)
+		}
+		for _, i := range g.Imports(gc) {
+			f.Imports[i] = struct{}{}
+		}
+	}
+
+	result := map[string][]byte{}
+	var formatErr error
+	sort.Strings(names)
+	for _, name := range names {
+		f := files[name]
+		ft, ok := c.FileTypes[f.FileType]
+		if !ok {
+			return nil, fmt.Errorf("file %q: unknown file type %q", name, f.FileType)
+		}
+		var b bytes.Buffer
+		if err := ft.Assemble(&b, f); err != nil {
+			return nil, fmt.Errorf("file %q: %v", name, err)
+		}
+		formatted, err := ft.Format(b.Bytes())
+		if err != nil {
+			if formatErr == nil {
+				formatErr = fmt.Errorf("file %q: %v", filepath.Join(p.Path(), name), err)
+			}
+			formatted = b.Bytes()
+		}
+		result[name] = formatted
+	}
+	return result, formatErr
+}
+
+// ExecutePackage renders p and writes its files to the directory p.Path()
+// below outputBase. Files which can't be formatted are written unformatted,
+// to help debugging, and an error is returned.
+func (c *Context) ExecutePackage(outputBase string, p Package) error {
+	files, err := c.Render(p)
+	if files == nil {
+		return err
+	}
+	dir := filepath.Join(outputBase, filepath.FromSlash(p.Path()))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// ExecutePackages executes all packages, stopping at the first error.
+func (c *Context) ExecutePackages(outputBase string, packages ...Package) error {
+	for _, p := range packages {
+		if err := c.ExecutePackage(outputBase, p); err != nil {
+			return fmt.Errorf("package %q: %v", p.Path(), err)
+		}
+	}
+	return nil
+}
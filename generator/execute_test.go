@@ -0,0 +1,119 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaolion/gen/namer"
+	"github.com/zhaolion/gen/types"
+)
+
+func testUniverse() types.Universe {
+	u := types.Universe{}
+	for _, name := range []string{"Foo", "Bar", "baz"} {
+		u.Type(types.Name{Package: "example.com/model", Name: name}).Kind = types.Struct
+	}
+	u.Type(types.Name{Package: "example.com/other", Name: "Other"}).Kind = types.Struct
+	return u
+}
+
+// stringerGen writes a String method for each type.
+type stringerGen struct {
+	DefaultGen
+}
+
+func (g *stringerGen) Filter(c *Context, t *types.Type) bool {
+	return t.IsExported()
+}
+
+func (g *stringerGen) Namers(c *Context) namer.NameSystems {
+	return namer.NameSystems{"short": namer.NamerFunc(func(t *types.Type) string { return t.Name.Name })}
+}
+
+func (g *stringerGen) Imports(c *Context) []string {
+	return []string{"fmt"}
+}
+
+func (g *stringerGen) GenerateType(c *Context, t *types.Type, w io.Writer) error {
+	name := c.Namers["short"].Name(t)
+	_, err := fmt.Fprintf(w, "func (x %s) String() string { return fmt.Sprint(%q) }\n", name, name)
+	return err
+}
+
+func TestExecutePackage(t *testing.T) {
+	c := NewContext(testUniverse(), "example.com/model")
+	assert.Equal(t, []string{"example.com/model.Bar", "example.com/model.Foo", "example.com/model.baz"}, names(c.Order))
+
+	p := &DefaultPackage{
+		PackageName: "model",
+		PackagePath: "example.com/model",
+		HeaderText:  []byte("// Code generated by test. DO NOT EDIT.\n"),
+		FilterFunc:  func(c *Context, t *types.Type) bool { return t.Name.Name != "Bar" },
+		GeneratorList: []Generator{
+			&stringerGen{DefaultGen{OptionalName: "zz_generated.string"}},
+			&DefaultGen{OptionalName: "zz_generated.string", OptionalBody: []byte("var _ = 1\n")},
+			&DefaultGen{OptionalName: "doc", OptionalBody: []byte("// Package model has models.\n")},
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "generator")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	assert.NoError(t, c.ExecutePackages(dir, p))
+
+	got, err := ioutil.ReadFile(filepath.Join(dir, "example.com", "model", "zz_generated.string.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, `// Code generated by test. DO NOT EDIT.
+
+package model
+
+import (
+	"fmt"
+)
+
+func (x Foo) String() string { return fmt.Sprint("Foo") }
+
+var _ = 1
+`, string(got))
+	_, err = os.Stat(filepath.Join(dir, "example.com", "model", "doc.go"))
+	assert.NoError(t, err)
+}
+
+func TestRenderErrors(t *testing.T) {
+	c := NewContext(testUniverse(), "example.com/model")
+	p := &DefaultPackage{
+		PackageName:   "model",
+		PackagePath:   "example.com/model",
+		GeneratorList: []Generator{&DefaultGen{OptionalName: "broken", OptionalBody: []byte("func {")}},
+	}
+	files, err := c.Render(p)
+	assert.Error(t, err)
+	assert.Equal(t, "package model\n\nfunc {", string(files["broken.go"]))
+
+	p.GeneratorList = []Generator{&badGen{DefaultGen{OptionalName: "bad"}}}
+	_, err = c.Render(p)
+	assert.EqualError(t, err, `generator "bad": type example.com/model.Bar: unsupported`)
+}
+
+type badGen struct {
+	DefaultGen
+}
+
+func (g *badGen) GenerateType(*Context, *types.Type, io.Writer) error {
+	return fmt.Errorf("unsupported")
+}
+
+func names(ts []*types.Type) []string {
+	var result []string
+	for _, t := range ts {
+		result = append(result, t.String())
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Names of the built in file types.
+const (
+	// GolangFileType files get a package clause and an import block, and are
+	// formatted with gofmt.
+	GolangFileType = "golang"
+	// TextFileType files are written as generated, after the header.
+	TextFileType = "text"
+)
+
+// File is a file being generated.
+type File struct {
+	Name        string
+	FileType    string
+	PackageName string
+	PackagePath string
+	Header      []byte
+
+	// Imports as returned by Generator.Imports.
+	Imports map[string]struct{}
+
+	Body bytes.Buffer
+}
+
+// FileType assembles and formats the files of a type.
+type FileType interface {
+	// Assemble writes the complete file.
+	Assemble(w io.Writer, f *File) error
+	// Format formats the assembled file.
+	Format(src []byte) ([]byte, error)
+}
+
+type golangFileType struct{}
+
+func (golangFileType) Assemble(w io.Writer, f *File) error {
+	var b bytes.Buffer
+	b.Write(f.Header)
+	if len(f.Header) > 0 && !bytes.HasSuffix(f.Header, []byte("\n\n")) {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "package %s\n\n", f.PackageName)
+
+	imports := make([]string, 0, len(f.Imports))
+	for i := range f.Imports {
+		imports = append(imports, importLine(i))
+	}
+	sort.Strings(imports)
+	if len(imports) > 0 {
+		b.WriteString("import (\n")
+		for _, i := range imports {
+			fmt.Fprintf(&b, "\t%s\n", i)
+		}
+		b.WriteString(")\n\n")
+	}
+	b.Write(f.Body.Bytes())
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func (golangFileType) Format(src []byte) ([]byte, error) {
+	return format.Source(src)
+}
+
+// importLine quotes bare import paths; `alias "path"` lines are kept.
+func importLine(i string) string {
+	if strings.HasSuffix(i, `"`) {
+		return i
+	}
+	return `"` + i + `"`
+}
+
+type textFileType struct{}
+
+func (textFileType) Assemble(w io.Writer, f *File) error {
+	if _, err := w.Write(f.Header); err != nil {
+		return err
+	}
+	_, err := w.Write(f.Body.Bytes())
+	return err
+}
+
+func (textFileType) Format(src []byte) ([]byte, error) {
+	return src, nil
+}
@@ -0,0 +1,152 @@
+// Package generator runs generators over a Universe and writes the files they
+// produce.
+package generator
+
+import (
+	"io"
+
+	"github.com/zhaolion/gen/namer"
+	"github.com/zhaolion/gen/types"
+)
+
+// Package describes one output package: where it goes, and which generators
+// produce its files.
+type Package interface {
+	// Name returns the package name, e.g. "model".
+	Name() string
+	// Path returns the import path of the package. Files are written to
+	// this path below the output base directory.
+	Path() string
+
+	// Filter is called on every type of Context.Order; only the types it
+	// returns true for are passed to the generators.
+	Filter(*Context, *types.Type) bool
+
+	// Header returns the header, e.g. a license boilerplate, of the given
+	// file.
+	Header(filename string) []byte
+
+	// Generators returns the generators of the package.
+	Generators(*Context) []Generator
+}
+
+// Generator produces (part of) a file. Several generators may write to the
+// same file; their output is concatenated in order.
+type Generator interface {
+	// Name returns the name of the generator, used in error messages.
+	Name() string
+
+	// Filter is called on every type passing the Package's filter; only the
+	// types it returns true for are passed to GenerateType.
+	Filter(*Context, *types.Type) bool
+
+	// Namers returns the name systems used by the generator, in addition to
+	// those of the Context. Name systems with the same name replace those of
+	// the Context.
+	Namers(*Context) namer.NameSystems
+
+	// Imports returns the imports the generated code needs, either as import
+	// paths or as `alias "import/path"` lines. It is called after Finalize.
+	Imports(*Context) []string
+
+	// Init writes the code before the types, e.g. declarations shared by
+	// them.
+	Init(*Context, io.Writer) error
+
+	// GenerateType writes the code for one type.
+	GenerateType(*Context, *types.Type, io.Writer) error
+
+	// Finalize writes the code after the types.
+	Finalize(*Context, io.Writer) error
+
+	// Filename returns the name of the file to write to, e.g.
+	// "zz_generated.deepcopy.go".
+	Filename() string
+
+	// FileType returns the type of the file, a key of Context.FileTypes.
+	FileType() string
+}
+
+// DefaultPackage is a Package configured by its fields.
+type DefaultPackage struct {
+	PackageName string
+	PackagePath string
+
+	// HeaderText is written to the top of every file.
+	HeaderText []byte
+
+	// GeneratorFunc returns the generators. If nil, GeneratorList is used.
+	GeneratorFunc func(*Context) []Generator
+	GeneratorList []Generator
+
+	// FilterFunc filters the types. If nil, all types are accepted.
+	FilterFunc func(*Context, *types.Type) bool
+}
+
+// Name returns PackageName.
+func (d *DefaultPackage) Name() string { return d.PackageName }
+
+// Path returns PackagePath.
+func (d *DefaultPackage) Path() string { return d.PackagePath }
+
+// Filter calls FilterFunc, if set.
+func (d *DefaultPackage) Filter(c *Context, t *types.Type) bool {
+	if d.FilterFunc != nil {
+		return d.FilterFunc(c, t)
+	}
+	return true
+}
+
+// Header returns HeaderText.
+func (d *DefaultPackage) Header(filename string) []byte {
+	return d.HeaderText
+}
+
+// Generators calls GeneratorFunc, if set, and returns GeneratorList
+// otherwise.
+func (d *DefaultPackage) Generators(c *Context) []Generator {
+	if d.GeneratorFunc != nil {
+		return d.GeneratorFunc(c)
+	}
+	return d.GeneratorList
+}
+
+// DefaultGen implements Generator with no-ops. Embed it in generators to only
+// implement the methods needed.
+type DefaultGen struct {
+	// OptionalName is returned by Name and, with ".go" appended, Filename.
+	OptionalName string
+
+	// OptionalBody is written by Init, if set.
+	OptionalBody []byte
+}
+
+// Name returns OptionalName.
+func (d DefaultGen) Name() string { return d.OptionalName }
+
+// Filter accepts all types.
+func (d DefaultGen) Filter(*Context, *types.Type) bool { return true }
+
+// Namers returns no name systems.
+func (d DefaultGen) Namers(*Context) namer.NameSystems { return nil }
+
+// Imports returns no imports.
+func (d DefaultGen) Imports(*Context) []string { return nil }
+
+// Init writes OptionalBody.
+func (d DefaultGen) Init(c *Context, w io.Writer) error {
+	_, err := w.Write(d.OptionalBody)
+	return err
+}
+
+// GenerateType writes nothing.
+func (d DefaultGen) GenerateType(*Context, *types.Type, io.Writer) error { return nil }
+
+// Finalize writes nothing.
+func (d DefaultGen) Finalize(*Context, io.Writer) error { return nil }
+
+// Filename returns OptionalName with ".go" appended.
+func (d DefaultGen) Filename() string { return d.OptionalName + ".go" }
+
+// FileType returns GolangFileType.
+func (d DefaultGen) FileType() string { return GolangFileType }
@@ -0,0 +1,231 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines around the changes of a hunk.
+const contextLines = 3
+
+// maxEdits bounds the work of the diff. Files differing more are shown as
+// replaced entirely.
+const maxEdits = 2000
+
+type edit struct {
+	op   byte // ' ', '-' or '+'
+	line string
+}
+
+// unifiedDiff returns the differences of old and new in the unified format,
+// or "" if they are equal. A nil old is a missing file.
+func unifiedDiff(oldName, newName string, old, new []byte) string {
+	if old != nil && string(old) == string(new) {
+		return ""
+	}
+	a, b := splitLines(old), splitLines(new)
+	edits := diffLines(a, b)
+
+	var out strings.Builder
+	if old == nil {
+		oldName = "/dev/null"
+	}
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+
+	// Line numbers of each edit in a and b, 0-based.
+	type pos struct{ a, b int }
+	positions := make([]pos, len(edits)+1)
+	for i, e := range edits {
+		p := positions[i]
+		switch e.op {
+		case ' ':
+			p.a, p.b = p.a+1, p.b+1
+		case '-':
+			p.a++
+		case '+':
+			p.b++
+		}
+		positions[i+1] = p
+	}
+
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+		// A hunk from the context before this change to the context after
+		// the last change less than 2*contextLines unchanged lines away.
+		start := i - contextLines
+		if start < 0 {
+			start = 0
+		}
+		for start < i && edits[start].op != ' ' {
+			start++
+		}
+		end := i
+		for end < len(edits) {
+			if edits[end].op != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(edits) && edits[next].op == ' ' {
+				next++
+			}
+			if next == len(edits) || next-end > 2*contextLines {
+				end += contextLines
+				if end > next {
+					end = next
+				}
+				break
+			}
+			end = next
+		}
+
+		countA, countB := 0, 0
+		for _, e := range edits[start:end] {
+			if e.op != '+' {
+				countA++
+			}
+			if e.op != '-' {
+				countB++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(positions[start].a, countA), hunkRange(positions[start].b, countB))
+		for _, e := range edits[start:end] {
+			out.WriteByte(e.op)
+			out.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return out.String()
+}
+
+// hunkRange formats the range of a hunk, with 1-based line numbers.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitLines splits s into lines, keeping their "\n".
+func splitLines(s []byte) []string {
+	var lines []string
+	for len(s) > 0 {
+		i := bytes.IndexByte(s, '\n') + 1
+		if i == 0 {
+			i = len(s)
+		}
+		lines = append(lines, string(s[:i]))
+		s = s[i:]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script turning a into b, using Myers'
+// algorithm.
+func diffLines(a, b []string) []edit {
+	// Common prefix and suffix.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	var edits []edit
+	for _, line := range a[:prefix] {
+		edits = append(edits, edit{' ', line})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, edit{' ', line})
+	}
+	return edits
+}
+
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	max := n + m
+	if max > maxEdits {
+		max = maxEdits
+	}
+	// v[k+offset] is the furthest x on diagonal k; trace[d] holds v[-d..d]
+	// before step d.
+	offset := max + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+	// Too many differences: replace everything.
+	var edits []edit
+	for _, line := range a {
+		edits = append(edits, edit{'-', line})
+	}
+	for _, line := range b {
+		edits = append(edits, edit{'+', line})
+	}
+	return edits
+}
+
+func backtrack(a, b []string, trace [][]int) []edit {
+	var reversed []edit
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		prevX, prevY := 0, 0
+		if d > 0 {
+			v := trace[d]
+			at := func(k int) int { return v[k+d] }
+			k := x - y
+			prevK := k - 1
+			if k == -d || (k != d && at(k-1) < at(k+1)) {
+				prevK = k + 1
+			}
+			prevX = at(prevK)
+			prevY = prevX - prevK
+		}
+		for x > prevX && y > prevY {
+			reversed = append(reversed, edit{' ', a[x-1]})
+			x, y = x-1, y-1
+		}
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, edit{'+', b[y-1]})
+			} else {
+				reversed = append(reversed, edit{'-', a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	edits := make([]edit, len(reversed))
+	for i, e := range reversed {
+		edits[len(edits)-1-i] = e
+	}
+	return edits
+}
@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// FileDiff is a generated file which differs from the file on disk.
+type FileDiff struct {
+	// The path of the file on disk.
+	Path string
+	// True if there is no file on disk.
+	Missing bool
+	// The differences from the file on disk to the generated file, in the
+	// unified diff format.
+	Diff string
+}
+
+// VerifyPackage renders p like ExecutePackage, but instead of writing its
+// files, compares them to those on disk. It returns the files which differ,
+// sorted by path. Nothing is written.
+func (c *Context) VerifyPackage(outputBase string, p Package) ([]FileDiff, error) {
+	files, err := c.Render(p)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(outputBase, filepath.FromSlash(p.Path()))
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var diffs []FileDiff
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		onDisk, err := ioutil.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		// Compare to nil for a missing file, and to an empty one otherwise.
+		if err == nil && onDisk == nil {
+			onDisk = []byte{}
+		}
+		if d := unifiedDiff(path, path+" (generated)", onDisk, files[name]); d != "" {
+			diffs = append(diffs, FileDiff{Path: path, Missing: onDisk == nil, Diff: d})
+		}
+	}
+	return diffs, nil
+}
+
+// VerifyPackages verifies all packages, stopping at the first error.
+func (c *Context) VerifyPackages(outputBase string, packages ...Package) ([]FileDiff, error) {
+	var diffs []FileDiff
+	for _, p := range packages {
+		d, err := c.VerifyPackage(outputBase, p)
+		if err != nil {
+			return nil, fmt.Errorf("package %q: %v", p.Path(), err)
+		}
+		diffs = append(diffs, d...)
+	}
+	return diffs, nil
+}
@@ -0,0 +1,128 @@
+package generator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d\n", i))
+	}
+	old := strings.Join(lines, "")
+	lines[1] = "changed 2\n"
+	lines = append(lines[:10], lines[11:]...)
+	lines = append(lines, "line 21")
+	new := strings.Join(lines, "")
+
+	assert.Equal(t, "", unifiedDiff("a", "b", []byte(old), []byte(old)))
+	assert.Equal(t, `--- a
++++ b
+@@ -1,5 +1,5 @@
+ line 1
+-line 2
++changed 2
+ line 3
+ line 4
+ line 5
+@@ -8,7 +8,6 @@
+ line 8
+ line 9
+ line 10
+-line 11
+ line 12
+ line 13
+ line 14
+@@ -18,3 +17,4 @@
+ line 18
+ line 19
+ line 20
++line 21
+\ No newline at end of file
+`, unifiedDiff("a", "b", []byte(old), []byte(new)))
+
+	assert.Equal(t, `--- /dev/null
++++ b
+@@ -0,0 +1,2 @@
++x
++y
+`, unifiedDiff("a", "b", nil, []byte("x\ny\n")))
+	assert.Equal(t, `--- a
++++ b
+@@ -1 +0,0 @@
+-x
+`, unifiedDiff("a", "b", []byte("x\n"), []byte("")))
+}
+
+func TestDiffLines(t *testing.T) {
+	a := strings.Split("a b c a b b a", " ")
+	b := strings.Split("c b a b a c", " ")
+	edits := diffLines(a, b)
+	var gotA, gotB []string
+	changes := 0
+	for _, e := range edits {
+		if e.op != '+' {
+			gotA = append(gotA, e.line)
+		}
+		if e.op != '-' {
+			gotB = append(gotB, e.line)
+		}
+		if e.op != ' ' {
+			changes++
+		}
+	}
+	assert.Equal(t, a, gotA)
+	assert.Equal(t, b, gotB)
+	// The shortest edit script of the example of Myers' paper.
+	assert.Equal(t, 5, changes)
+}
+
+func TestVerifyPackage(t *testing.T) {
+	c := NewContext(testUniverse(), "example.com/model")
+	p := &DefaultPackage{
+		PackageName: "model",
+		PackagePath: "example.com/model",
+		GeneratorList: []Generator{
+			&stringerGen{DefaultGen{OptionalName: "zz_generated.string"}},
+			&DefaultGen{OptionalName: "doc", OptionalBody: []byte("// Package model has models.\n")},
+		},
+	}
+	dir, err := ioutil.TempDir("", "verify")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	assert.NoError(t, c.ExecutePackage(dir, p))
+
+	diffs, err := c.VerifyPackages(dir, p)
+	assert.NoError(t, err)
+	assert.Empty(t, diffs)
+
+	pkgDir := filepath.Join(dir, "example.com", "model")
+	assert.NoError(t, os.Remove(filepath.Join(pkgDir, "doc.go")))
+	stale := filepath.Join(pkgDir, "zz_generated.string.go")
+	content, _ := ioutil.ReadFile(stale)
+	assert.NoError(t, ioutil.WriteFile(stale, []byte(strings.Replace(string(content), `"Bar"`, `"Baz"`, 1)), 0644))
+
+	diffs, err = c.VerifyPackage(dir, p)
+	assert.NoError(t, err)
+	if assert.Len(t, diffs, 2) {
+		assert.Equal(t, filepath.Join(pkgDir, "doc.go"), diffs[0].Path)
+		assert.True(t, diffs[0].Missing)
+		assert.Equal(t, stale, diffs[1].Path)
+		assert.False(t, diffs[1].Missing)
+		assert.Contains(t, diffs[1].Diff, "--- "+stale+"\n+++ "+stale+" (generated)\n")
+		assert.Contains(t, diffs[1].Diff, "\n-func (x Bar) String() string { return fmt.Sprint(\"Baz\") }\n+func (x Bar) String() string { return fmt.Sprint(\"Bar\") }\n")
+	}
+
+	// Nothing was written.
+	_, err = os.Stat(filepath.Join(pkgDir, "doc.go"))
+	assert.True(t, os.IsNotExist(err))
+}
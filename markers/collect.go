@@ -0,0 +1,128 @@
+package markers
+
+import (
+	"sort"
+
+	"github.com/zhaolion/gen/types"
+)
+
+// Collection holds the markers found in a Universe, indexed by the
+// declaration they are attached to.
+type Collection struct {
+	packages  map[string]Markers
+	types     map[types.Name]Markers
+	fields    map[types.Name]map[string]Markers
+	functions map[types.Name]Markers
+	methods   map[types.Name]map[string]Markers
+}
+
+// Package returns the markers in the package comment of p.
+func (c *Collection) Package(p *types.Package) Markers {
+	return c.packages[p.Path]
+}
+
+// Type returns the markers in the doc comment of t.
+func (c *Collection) Type(t *types.Type) Markers {
+	return c.types[t.Name]
+}
+
+// Field returns the markers in the doc and trailing comments of the named
+// field of struct t.
+func (c *Collection) Field(t *types.Type, field string) Markers {
+	return c.fields[t.Name][field]
+}
+
+// Function returns the markers in the doc comment of function f.
+func (c *Collection) Function(f *types.Type) Markers {
+	return c.functions[f.Name]
+}
+
+// Method returns the markers in the doc comment of the named method of t.
+func (c *Collection) Method(t *types.Type, method string) Markers {
+	return c.methods[t.Name][method]
+}
+
+// Collect extracts and validates the registered markers of the given
+// packages in u. If no packages are given, all packages which were loaded
+// from source (i.e. have Files) are used. All invalid markers are reported,
+// as an ErrorList.
+func (r *Registry) Collect(u types.Universe, packagePaths ...string) (*Collection, error) {
+	c := &Collection{
+		packages:  map[string]Markers{},
+		types:     map[types.Name]Markers{},
+		fields:    map[types.Name]map[string]Markers{},
+		functions: map[types.Name]Markers{},
+		methods:   map[types.Name]map[string]Markers{},
+	}
+	if len(packagePaths) == 0 {
+		for path, p := range u {
+			if len(p.Files) > 0 {
+				packagePaths = append(packagePaths, path)
+			}
+		}
+		sort.Strings(packagePaths)
+	}
+
+	var errs ErrorList
+	parse := func(target Target, where string, lines []string) Markers {
+		ms, err := r.Parse(target, lines)
+		if list, ok := err.(ErrorList); ok {
+			for _, e := range list {
+				e.Target = where
+				errs = append(errs, e)
+			}
+		}
+		return ms
+	}
+
+	for _, path := range packagePaths {
+		p, ok := u.LookupPackage(path)
+		if !ok {
+			continue
+		}
+		c.packages[path] = parse(PackageTarget, path, packageComments(p))
+
+		for _, t := range p.SortedTypes() {
+			c.types[t.Name] = parse(TypeTarget, t.Name.String(), t.CommentLines)
+			if t.Kind == types.Struct {
+				c.fields[t.Name] = map[string]Markers{}
+				for _, m := range t.Members {
+					lines := append(append([]string{}, m.CommentLines...), m.TrailingCommentLines...)
+					c.fields[t.Name][m.Name] = parse(FieldTarget, t.Name.String()+"."+m.Name, lines)
+				}
+			}
+			if len(t.Methods) > 0 {
+				c.methods[t.Name] = map[string]Markers{}
+				for _, name := range t.MethodNames() {
+					c.methods[t.Name][name] = parse(MethodTarget, t.Name.String()+"."+name, t.Methods[name].CommentLines)
+				}
+			}
+		}
+		for _, f := range p.SortedFunctions() {
+			c.functions[f.Name] = parse(FunctionTarget, f.Name.String(), f.CommentLines)
+		}
+	}
+
+	if len(errs) > 0 {
+		return c, errs
+	}
+	return c, nil
+}
+
+// packageComments returns the package comments of all files of p, falling
+// back to p.DocComments.
+func packageComments(p *types.Package) []string {
+	if len(p.Files) == 0 {
+		return p.DocComments
+	}
+	paths := make([]string, 0, len(p.Files))
+	for path := range p.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var lines []string
+	for _, path := range paths {
+		lines = append(lines, p.Files[path].DocComments...)
+	}
+	return lines
+}
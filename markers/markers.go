@@ -0,0 +1,286 @@
+package markers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Marker is a single annotation in a comment, of the form
+//
+//	@name positional key=value, "quoted value"
+//
+// Arguments are separated by commas or whitespace. A marker must be the only
+// thing on its comment line.
+type Marker struct {
+	// The name of the marker, without the leading '@'.
+	Name string
+
+	// Arguments without a key, in order.
+	Positional []string
+
+	// Arguments of the form key=value, indexed by key.
+	Named map[string]string
+
+	// Typed argument values, indexed by argument name. Only set for markers
+	// validated against a Definition, see Registry.
+	Values map[string]interface{}
+
+	// The comment line the marker was parsed from.
+	Text string
+}
+
+// String returns the value of the named argument as a string.
+func (m Marker) String(arg string) string {
+	s, _ := m.Values[arg].(string)
+	return s
+}
+
+// Int returns the value of the named argument as an int.
+func (m Marker) Int(arg string) int {
+	i, _ := m.Values[arg].(int)
+	return i
+}
+
+// Bool returns the value of the named argument as a bool.
+func (m Marker) Bool(arg string) bool {
+	b, _ := m.Values[arg].(bool)
+	return b
+}
+
+// Strings returns the value of the named argument as a list of strings.
+func (m Marker) Strings(arg string) []string {
+	s, _ := m.Values[arg].([]string)
+	return s
+}
+
+// Has returns true if the named argument was given or has a default.
+func (m Marker) Has(arg string) bool {
+	_, ok := m.Values[arg]
+	return ok
+}
+
+// Markers is a list of markers in the order they appear in the comments.
+type Markers []Marker
+
+// Get returns the first marker with the given name, or nil.
+func (ms Markers) Get(name string) *Marker {
+	for i := range ms {
+		if ms[i].Name == name {
+			return &ms[i]
+		}
+	}
+	return nil
+}
+
+// All returns all markers with the given name.
+func (ms Markers) All(name string) Markers {
+	var result Markers
+	for _, m := range ms {
+		if m.Name == name {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// Has returns true if there is a marker with the given name.
+func (ms Markers) Has(name string) bool {
+	return ms.Get(name) != nil
+}
+
+// IsMarker returns true if the comment line is a marker line.
+func IsMarker(line string) bool {
+	line = strings.TrimSpace(line)
+	return len(line) > 1 && line[0] == '@' && isNameStart(rune(line[1]))
+}
+
+// Parse extracts the markers from comment lines, as found in e.g.
+// types.Type.CommentLines. Lines which are not markers are skipped.
+func Parse(lines []string) (Markers, error) {
+	var result Markers
+	for _, line := range lines {
+		if !IsMarker(line) {
+			continue
+		}
+		m, err := ParseLine(line)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *m)
+	}
+	return result, nil
+}
+
+// ParseLine parses a single marker line, without validating it against a
+// Definition.
+func ParseLine(line string) (*Marker, error) {
+	text := strings.TrimSpace(line)
+	if !strings.HasPrefix(text, "@") {
+		return nil, &Error{Text: line, Msg: "marker must start with '@'"}
+	}
+
+	name := markerName(text)
+	end := 1 + len(name)
+	m := &Marker{Name: name, Named: map[string]string{}, Text: line}
+	if !isValidName(m.Name) {
+		return nil, &Error{Text: line, Msg: fmt.Sprintf("invalid marker name %q", m.Name)}
+	}
+
+	args, err := splitArgs(text[end:])
+	if err != nil {
+		return nil, &Error{Text: line, Msg: err.Error()}
+	}
+	for _, arg := range args {
+		if arg.key == "" {
+			m.Positional = append(m.Positional, arg.value)
+			continue
+		}
+		if _, ok := m.Named[arg.key]; ok {
+			return nil, &Error{Text: line, Msg: fmt.Sprintf("argument %q given more than once", arg.key)}
+		}
+		m.Named[arg.key] = arg.value
+	}
+	return m, nil
+}
+
+// markerName returns the name of the marker on line, which may not be a
+// valid name: the text after the '@' up to the first space.
+func markerName(line string) string {
+	text := strings.TrimPrefix(strings.TrimSpace(line), "@")
+	if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+		return text[:i]
+	}
+	return text
+}
+
+type rawArg struct {
+	key   string
+	value string
+}
+
+// splitArgs splits the argument part of a marker line.
+func splitArgs(s string) ([]rawArg, error) {
+	var (
+		args []rawArg
+		// Set after a comma, so that "a,,b" and a trailing comma are errors.
+		needArg bool
+	)
+	for i := 0; ; {
+		for i < len(s) && unicode.IsSpace(rune(s[i])) {
+			i++
+		}
+		if i == len(s) {
+			if needArg {
+				return nil, fmt.Errorf("missing argument after ','")
+			}
+			return args, nil
+		}
+		if s[i] == ',' {
+			if needArg || len(args) == 0 {
+				return nil, fmt.Errorf("empty argument at offset %d", i)
+			}
+			needArg = true
+			i++
+			continue
+		}
+		needArg = false
+
+		var (
+			arg rawArg
+			err error
+		)
+		arg.value, i, err = scanValue(s, i)
+		if err != nil {
+			return nil, err
+		}
+		if i < len(s) && s[i] == '=' {
+			arg.key = arg.value
+			if !isValidName(arg.key) {
+				return nil, fmt.Errorf("invalid argument name %q", arg.key)
+			}
+			if arg.value, i, err = scanValue(s, i+1); err != nil {
+				return nil, err
+			}
+		}
+		args = append(args, arg)
+	}
+}
+
+// scanValue scans a plain or double quoted value starting at s[i], returning
+// the value and the index after it.
+func scanValue(s string, i int) (string, int, error) {
+	if i < len(s) && s[i] == '"' {
+		for j := i + 1; j < len(s); j++ {
+			switch s[j] {
+			case '\\':
+				j++
+			case '"':
+				v, err := strconv.Unquote(s[i : j+1])
+				if err != nil {
+					return "", 0, fmt.Errorf("invalid quoted value %s", s[i:j+1])
+				}
+				return v, j + 1, nil
+			}
+		}
+		return "", 0, fmt.Errorf("unterminated quoted value %s", s[i:])
+	}
+	start := i
+	for i < len(s) && s[i] != ',' && s[i] != '=' && s[i] != '"' && !unicode.IsSpace(rune(s[i])) {
+		i++
+	}
+	if i < len(s) && s[i] == '"' {
+		return "", 0, fmt.Errorf("unexpected '\"' in %q", s[start:])
+	}
+	if start == i {
+		return "", 0, fmt.Errorf("missing value at offset %d", start)
+	}
+	return s[start:i], i, nil
+}
+
+func isNameStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_'
+}
+
+// isValidName returns true for names like "gen", "gen:json" or "json.name".
+func isValidName(name string) bool {
+	if name == "" || !isNameStart(rune(name[0])) {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("_-.:", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Error is a malformed or invalid marker.
+type Error struct {
+	// Where the marker was found, e.g. "github.com/x/model.Foo.Name".
+	Target string
+	// The comment line of the marker.
+	Text string
+	// What is wrong with it.
+	Msg string
+}
+
+func (e *Error) Error() string {
+	text := strings.TrimSpace(e.Text)
+	if e.Target == "" {
+		return fmt.Sprintf("invalid marker %q: %s", text, e.Msg)
+	}
+	return fmt.Sprintf("%s: invalid marker %q: %s", e.Target, text, e.Msg)
+}
+
+// ErrorList is a list of marker errors.
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	msgs := make([]string, 0, len(l))
+	for _, e := range l {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
@@ -0,0 +1,137 @@
+package markers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaolion/gen/parser/parsertest"
+)
+
+func TestParseLine(t *testing.T) {
+	cases := []struct {
+		line       string
+		name       string
+		positional []string
+		named      map[string]string
+		err        string
+	}{
+		{line: "@gen", name: "gen", named: map[string]string{}},
+		{line: " @gen CtxFoo", name: "gen", positional: []string{"CtxFoo"}, named: map[string]string{}},
+		{line: "@gen foo name", name: "gen", positional: []string{"foo", "name"}, named: map[string]string{}},
+		{
+			line:  `@gen:json name=foo, omit=true,desc="a, b"`,
+			name:  "gen:json",
+			named: map[string]string{"name": "foo", "omit": "true", "desc": "a, b"},
+		},
+		{line: "@gen a,", err: `invalid marker "@gen a,": missing argument after ','`},
+		{line: "@gen ,a", err: `invalid marker "@gen ,a": empty argument at offset 1`},
+		{line: "@gen a=", err: `invalid marker "@gen a=": missing value at offset 3`},
+		{line: "@gen 1a=b", err: `invalid marker "@gen 1a=b": invalid argument name "1a"`},
+		{line: `@gen a="b`, err: `invalid marker "@gen a=\"b": unterminated quoted value "b`},
+		{line: "@gen a=1 a=2", err: `invalid marker "@gen a=1 a=2": argument "a" given more than once`},
+		{line: "@1gen", err: `invalid marker "@1gen": invalid marker name "1gen"`},
+	}
+	for _, c := range cases {
+		m, err := ParseLine(c.line)
+		if c.err != "" {
+			if assert.Error(t, err, c.line) {
+				assert.Equal(t, c.err, err.Error())
+			}
+			continue
+		}
+		if assert.NoError(t, err, c.line) {
+			assert.Equal(t, c.name, m.Name, c.line)
+			assert.Equal(t, c.positional, m.Positional, c.line)
+			assert.Equal(t, c.named, m.Named, c.line)
+		}
+	}
+}
+
+func TestRegistryParse(t *testing.T) {
+	r, err := NewRegistry(&Definition{
+		Name:    "json",
+		Targets: []Target{FieldTarget},
+		Args: []Argument{
+			{Name: "name"},
+			{Name: "omitempty", Type: BoolArg, Optional: true, Default: "false"},
+			{Name: "order", Type: IntArg, Optional: true},
+			{Name: "groups", Type: StringsArg, Optional: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ms, err := r.Parse(FieldTarget, []string{
+		"Name of foo",
+		"@json foo order=2 groups=a;b",
+		"@unknown marker",
+		"@foo( in prose is not a marker",
+		"@unknown \"unterminated",
+	})
+	if assert.NoError(t, err) && assert.Len(t, ms, 1) {
+		m := ms.Get("json")
+		assert.Equal(t, "foo", m.String("name"))
+		assert.Equal(t, false, m.Bool("omitempty"))
+		assert.Equal(t, 2, m.Int("order"))
+		assert.Equal(t, []string{"a", "b"}, m.Strings("groups"))
+	}
+
+	_, err = r.Parse(FieldTarget, []string{
+		"@json",
+		"@json foo order=x",
+		"@json foo bar baz",
+		"@json foo",
+		"@json bar",
+	})
+	assert.EqualError(t, err, `invalid marker "@json": missing required argument "name"
+invalid marker "@json foo order=x": argument "order": "x" is not an int
+invalid marker "@json foo bar baz": argument "omitempty": "bar" is not a bool
+invalid marker "@json bar": marker may only be given once`)
+
+	_, err = r.Parse(FieldTarget, []string{"@json foo order=x"})
+	assert.EqualError(t, err, `invalid marker "@json foo order=x": argument "order": "x" is not an int`)
+	_, err = r.Parse(FieldTarget, []string{"@json foo true bar"})
+	assert.EqualError(t, err, `invalid marker "@json foo true bar": argument "order": "bar" is not an int`)
+	_, err = r.Parse(TypeTarget, []string{"@json foo"})
+	assert.EqualError(t, err, `invalid marker "@json foo": not allowed on a type`)
+
+	assert.Error(t, r.Register(&Definition{Name: "json"}))
+	assert.Error(t, r.Register(&Definition{Name: "bad", Args: []Argument{{Name: "a", Type: "float"}}}))
+}
+
+func TestRegistryCollect(t *testing.T) {
+	universe := parsertest.Universe(t)
+
+	r, err := NewRegistry(&Definition{
+		Name:    "gen",
+		Targets: []Target{FieldTarget, MethodTarget},
+		Args:    []Argument{{Name: "kind"}, {Name: "name", Optional: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := r.Collect(universe)
+	if err != nil {
+		t.Fatalf("invalid Collect err: %+v", err)
+	}
+
+	service := universe.Package("github.com/zhaolion/gen/parser/testpkg/a1").Type("Service")
+	m := c.Method(service, "Foo").Get("gen")
+	if assert.NotNil(t, m) {
+		assert.Equal(t, "CtxFoo", m.String("kind"))
+		assert.False(t, m.Has("name"))
+	}
+
+	foo := universe.Package("github.com/zhaolion/gen/parser/testpkg/model").Type("Foo")
+	m = c.Field(foo, "Name").Get("gen")
+	if assert.NotNil(t, m) {
+		assert.Equal(t, "foo", m.String("kind"))
+		assert.Equal(t, "name", m.String("name"))
+	}
+	assert.Empty(t, c.Type(foo))
+
+	r, _ = NewRegistry(&Definition{Name: "gen", Targets: []Target{TypeTarget}})
+	_, err = r.Collect(universe, "github.com/zhaolion/gen/parser/testpkg/model")
+	assert.EqualError(t, err, `github.com/zhaolion/gen/parser/testpkg/model.Foo.Name: invalid marker "@gen foo name": not allowed on a field`)
+}
@@ -0,0 +1,243 @@
+package markers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Target is the kind of declaration a marker is attached to.
+type Target string
+
+const (
+	PackageTarget  Target = "package"
+	TypeTarget     Target = "type"
+	FieldTarget    Target = "field"
+	FunctionTarget Target = "function"
+	MethodTarget   Target = "method"
+)
+
+// ArgType is the type of a marker argument value.
+type ArgType string
+
+const (
+	StringArg ArgType = "string"
+	IntArg    ArgType = "int"
+	BoolArg   ArgType = "bool"
+	// StringsArg is a list of strings separated by ';', e.g. `fields=a;b`.
+	StringsArg ArgType = "strings"
+)
+
+// Argument describes a single marker argument.
+type Argument struct {
+	// The key of the argument in key=value form.
+	Name string
+
+	// The type of the value. Defaults to StringArg.
+	Type ArgType
+
+	// If true, the argument may be left out.
+	Optional bool
+
+	// The value used if an optional argument is left out, as written in a
+	// marker. Ignored if empty.
+	Default string
+}
+
+// Definition describes a marker and the arguments it takes. Positional
+// arguments are assigned, in order, to the arguments which were not given in
+// key=value form.
+type Definition struct {
+	// The name of the marker, without the leading '@'.
+	Name string
+
+	// The declarations the marker may be attached to. Any if empty.
+	Targets []Target
+
+	// The arguments of the marker.
+	Args []Argument
+
+	// If true, the marker may appear more than once on a declaration.
+	Repeatable bool
+}
+
+// Arg returns the argument with the given name, or nil.
+func (d *Definition) Arg(name string) *Argument {
+	for i := range d.Args {
+		if d.Args[i].Name == name {
+			return &d.Args[i]
+		}
+	}
+	return nil
+}
+
+func (d *Definition) allows(target Target) bool {
+	if len(d.Targets) == 0 {
+		return true
+	}
+	for _, t := range d.Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// validate fills in m.Values, or returns what is wrong with m.
+func (d *Definition) validate(m *Marker, target Target) error {
+	if !d.allows(target) {
+		return fmt.Errorf("not allowed on a %s", target)
+	}
+
+	raw := map[string]string{}
+	for k, v := range m.Named {
+		if d.Arg(k) == nil {
+			return fmt.Errorf("unknown argument %q", k)
+		}
+		raw[k] = v
+	}
+	positional := m.Positional
+	for _, arg := range d.Args {
+		if _, ok := raw[arg.Name]; ok || len(positional) == 0 {
+			continue
+		}
+		raw[arg.Name], positional = positional[0], positional[1:]
+	}
+	if len(positional) > 0 {
+		return fmt.Errorf("too many arguments, unexpected %q", strings.Join(positional, " "))
+	}
+
+	m.Values = map[string]interface{}{}
+	for _, arg := range d.Args {
+		s, ok := raw[arg.Name]
+		if !ok {
+			if !arg.Optional {
+				return fmt.Errorf("missing required argument %q", arg.Name)
+			}
+			if arg.Default == "" {
+				continue
+			}
+			s = arg.Default
+		}
+		v, err := convert(arg.Type, s)
+		if err != nil {
+			return fmt.Errorf("argument %q: %v", arg.Name, err)
+		}
+		m.Values[arg.Name] = v
+	}
+	return nil
+}
+
+func convert(t ArgType, s string) (interface{}, error) {
+	switch t {
+	case StringArg, "":
+		return s, nil
+	case IntArg:
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an int", s)
+		}
+		return i, nil
+	case BoolArg:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a bool", s)
+		}
+		return b, nil
+	case StringsArg:
+		return strings.Split(s, ";"), nil
+	default:
+		return nil, fmt.Errorf("unknown argument type %q", t)
+	}
+}
+
+// Registry holds the known marker definitions. Markers without a definition
+// are ignored when parsing through a Registry.
+type Registry struct {
+	defs map[string]*Definition
+}
+
+// NewRegistry constructs a registry with the given definitions.
+func NewRegistry(defs ...*Definition) (*Registry, error) {
+	r := &Registry{defs: map[string]*Definition{}}
+	if err := r.Register(defs...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Register adds marker definitions to the registry.
+func (r *Registry) Register(defs ...*Definition) error {
+	for _, d := range defs {
+		if !isValidName(d.Name) {
+			return fmt.Errorf("invalid marker name %q", d.Name)
+		}
+		if _, ok := r.defs[d.Name]; ok {
+			return fmt.Errorf("marker %q is already registered", d.Name)
+		}
+		seen := map[string]bool{}
+		for _, arg := range d.Args {
+			if !isValidName(arg.Name) || seen[arg.Name] {
+				return fmt.Errorf("marker %q: invalid or duplicate argument %q", d.Name, arg.Name)
+			}
+			seen[arg.Name] = true
+			switch arg.Type {
+			case "", StringArg, IntArg, BoolArg, StringsArg:
+			default:
+				return fmt.Errorf("marker %q: argument %q has unknown type %q", d.Name, arg.Name, arg.Type)
+			}
+			if arg.Default == "" {
+				continue
+			}
+			if _, err := convert(arg.Type, arg.Default); err != nil {
+				return fmt.Errorf("marker %q: default of argument %q: %v", d.Name, arg.Name, err)
+			}
+		}
+		r.defs[d.Name] = d
+	}
+	return nil
+}
+
+// Lookup returns the definition of the named marker, or nil.
+func (r *Registry) Lookup(name string) *Definition {
+	return r.defs[name]
+}
+
+// Parse extracts and validates the registered markers in comment lines
+// attached to a declaration of the given target kind. Lines which are not
+// registered markers are skipped, all invalid markers are reported, as an
+// ErrorList.
+func (r *Registry) Parse(target Target, lines []string) (Markers, error) {
+	var (
+		result Markers
+		errs   ErrorList
+	)
+	seen := map[string]bool{}
+	for _, line := range lines {
+		// Unregistered markers, or prose which looks like one, are not
+		// ours to report.
+		d := r.defs[markerName(line)]
+		if !IsMarker(line) || d == nil {
+			continue
+		}
+		m, err := ParseLine(line)
+		if err != nil {
+			errs = append(errs, err.(*Error))
+			continue
+		}
+		if seen[m.Name] && !d.Repeatable {
+			errs = append(errs, &Error{Text: line, Msg: "marker may only be given once"})
+			continue
+		}
+		if err := d.validate(m, target); err != nil {
+			errs = append(errs, &Error{Text: line, Msg: err.Error()})
+			continue
+		}
+		seen[m.Name] = true
+		result = append(result, *m)
+	}
+	if len(errs) > 0 {
+		return result, errs
+	}
+	return result, nil
+}
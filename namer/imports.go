@@ -0,0 +1,249 @@
+package namer
+
+import (
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zhaolion/gen/types"
+)
+
+// ImportTracker allocates the local names of the packages imported by a
+// generated file, and emits its import block. Local names never conflict with
+// each other, with Go keywords or with reserved names.
+type ImportTracker struct {
+	// The import path of the package of the generated file. Its types need
+	// no import.
+	local string
+
+	// PackageName returns the declared name of the package with the given
+	// import path, if known. By default, it is derived from the path.
+	PackageName func(importPath string) string
+
+	pathToName map[string]string
+	nameToPath map[string]string
+	reserved   map[string]bool
+}
+
+// NewImportTracker returns an ImportTracker for a file of the package with
+// the given import path, and adds the packages of the given types.
+func NewImportTracker(localPackage string, ts ...*types.Type) *ImportTracker {
+	t := &ImportTracker{
+		local:       localPackage,
+		PackageName: DefaultPackageName,
+		pathToName:  map[string]string{},
+		nameToPath:  map[string]string{},
+		reserved:    map[string]bool{},
+	}
+	t.AddTypes(ts...)
+	return t
+}
+
+// NewUniverseImportTracker is like NewImportTracker, but takes the package
+// names from u where they are known.
+func NewUniverseImportTracker(u types.Universe, localPackage string, ts ...*types.Type) *ImportTracker {
+	t := NewImportTracker(localPackage)
+	t.PackageName = func(importPath string) string {
+		if p, ok := u.LookupPackage(importPath); ok && p.Name != "" {
+			return p.Name
+		}
+		return DefaultPackageName(importPath)
+	}
+	t.AddTypes(ts...)
+	return t
+}
+
+// Reserve prevents the given names from being used as local names, e.g.
+// because the generated code declares them.
+func (t *ImportTracker) Reserve(names ...string) {
+	for _, name := range names {
+		t.reserved[name] = true
+	}
+}
+
+// AddTypes adds the packages of the named types referenced by ts.
+func (t *ImportTracker) AddTypes(ts ...*types.Type) {
+	for _, typ := range ts {
+		t.addType(typ, map[*types.Type]bool{})
+	}
+}
+
+func (t *ImportTracker) addType(typ *types.Type, seen map[*types.Type]bool) {
+	if typ == nil || seen[typ] {
+		return
+	}
+	seen[typ] = true
+	switch {
+	case typ.Kind == types.Protobuf:
+		t.AddPath(typ.Name.Path)
+		return
+	case isNamed(typ):
+		t.AddPath(typ.Name.Package)
+		return
+	}
+	t.addType(typ.Key, seen)
+	t.addType(typ.Elem, seen)
+	for _, m := range typ.Members {
+		t.addType(m.Type, seen)
+	}
+	for _, m := range typ.Methods {
+		t.addType(m, seen)
+	}
+	if s := typ.Signature; s != nil {
+		for _, p := range s.Parameters {
+			t.addType(p, seen)
+		}
+		for _, r := range s.Results {
+			t.addType(r, seen)
+		}
+	}
+}
+
+// AddPath adds an import path, and returns its local name.
+func (t *ImportTracker) AddPath(importPath string) string {
+	return t.LocalNameOf(importPath)
+}
+
+// LocalNameOf returns the local name of the package with the given import
+// path, adding it if needed. The local package has no local name.
+func (t *ImportTracker) LocalNameOf(importPath string) string {
+	if importPath == "" || importPath == t.local {
+		return ""
+	}
+	if name, ok := t.pathToName[importPath]; ok {
+		return name
+	}
+	name := t.allocate(importPath)
+	t.pathToName[importPath] = name
+	t.nameToPath[name] = importPath
+	return name
+}
+
+// PathOf returns the import path of the package with the given local name.
+func (t *ImportTracker) PathOf(localName string) (string, bool) {
+	p, ok := t.nameToPath[localName]
+	return p, ok
+}
+
+// allocate picks a free local name: the package name, then the package name
+// prefixed with up to two parent directories, then numbered.
+func (t *ImportTracker) allocate(importPath string) string {
+	base := sanitize(t.PackageName(importPath))
+	candidates := []string{base}
+	dirs := strings.Split(importPath, "/")
+	prefix := base
+	for i := len(dirs) - 2; i >= 0 && i >= len(dirs)-3; i-- {
+		prefix = sanitize(dirs[i]) + prefix
+		candidates = append(candidates, prefix)
+	}
+	for _, c := range candidates {
+		if t.free(c) {
+			return c
+		}
+	}
+	for i := 2; ; i++ {
+		c := base + strconv.Itoa(i)
+		if t.free(c) {
+			return c
+		}
+	}
+}
+
+func (t *ImportTracker) free(name string) bool {
+	_, used := t.nameToPath[name]
+	return name != "" && name != "_" && !used && !t.reserved[name] && !token.IsKeyword(name)
+}
+
+// ImportLines returns the import specs, sorted by path: `"path"` if the
+// local name is both the package name and the last element of the path,
+// `name "path"` otherwise. Packages which are not in the Universe may not be
+// named after the last element, e.g. "k8s.io/api/core/v1" is named v1, so
+// their name guessed by DefaultPackageName is only trusted if it is that
+// element.
+func (t *ImportTracker) ImportLines() []string {
+	paths := make([]string, 0, len(t.pathToName))
+	for p := range t.pathToName {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	lines := make([]string, 0, len(paths))
+	for _, p := range paths {
+		name := t.pathToName[p]
+		if name == t.PackageName(p) && name == path.Base(p) {
+			lines = append(lines, `"`+p+`"`)
+		} else {
+			lines = append(lines, name+` "`+p+`"`)
+		}
+	}
+	return lines
+}
+
+// ImportBlock returns the import declaration of the tracked packages, or ""
+// if there are none.
+func (t *ImportTracker) ImportBlock() string {
+	lines := t.ImportLines()
+	if len(lines) == 0 {
+		return ""
+	}
+	return "import (\n\t" + strings.Join(lines, "\n\t") + "\n)\n"
+}
+
+// DefaultPackageName guesses the name of a package from its import path: the
+// last element, skipping major version elements like "v2", without version
+// suffixes like ".v2" and "go-" prefixes.
+func DefaultPackageName(importPath string) string {
+	dirs := strings.Split(importPath, "/")
+	name := dirs[len(dirs)-1]
+	if len(dirs) > 1 && isMajorVersion(name) {
+		name = dirs[len(dirs)-2]
+	}
+	if i := strings.Index(name, ".v"); i > 0 && isMajorVersion(name[i+1:]) {
+		name = name[:i]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	return sanitize(path.Base(name))
+}
+
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	for _, c := range s[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// sanitize turns s into a lower case identifier.
+func sanitize(s string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(s) {
+		switch {
+		case c >= 'a' && c <= 'z', c == '_':
+			b.WriteRune(c)
+		case c >= '0' && c <= '9':
+			if b.Len() == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// isNamed returns true for types which are referred to by name, i.e. have a
+// package, and the predeclared error type. Methods have a package too, but
+// are referred to by their signature.
+func isNamed(t *types.Type) bool {
+	if t.Name.Package == "" && t.Name.Name == "error" {
+		return true
+	}
+	if t.Name.Package == "" || t.Kind == types.DeclarationOf {
+		return false
+	}
+	return t.Kind != types.Func || t.Signature == nil || (t.Signature.Receiver == nil && t.Signature.MethodName == "")
+}
@@ -0,0 +1,40 @@
+// Package namer has the name systems used by generators to turn types into
+// names.
+package namer
+
+import (
+	"github.com/zhaolion/gen/types"
+)
+
+// Namer returns a name for a type. Namers are used by generators to refer to
+// types in the code they emit.
+type Namer interface {
+	Name(*types.Type) string
+}
+
+// NameSystems is a map of name system names to Namers, e.g. "public" and
+// "private".
+type NameSystems map[string]Namer
+
+// NamerFunc adapts a function to a Namer.
+type NamerFunc func(*types.Type) string
+
+// Name calls f(t).
+func (f NamerFunc) Name(t *types.Type) string {
+	return f(t)
+}
+
+// DefaultNameSystems returns the name systems most generators need, for code
+// of the package with import path pkg:
+//   - "raw": Go expressions of types, see NewRawNamer.
+//   - "public": exported identifiers, see NewPublicNamer.
+//   - "private": unexported identifiers, see NewPrivateNamer.
+//
+// A generator returns tracker.ImportLines() from its Imports method.
+func DefaultNameSystems(pkg string, tracker *ImportTracker) NameSystems {
+	return NameSystems{
+		"raw":     NewRawNamer(pkg, tracker),
+		"public":  NewPublicNamer(0),
+		"private": NewPrivateNamer(0),
+	}
+}
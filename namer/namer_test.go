@@ -0,0 +1,203 @@
+package namer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhaolion/gen/parser/parsertest"
+	"github.com/zhaolion/gen/types"
+)
+
+const (
+	localPkg = "example.com/app/api"
+	modelPkg = "example.com/app/model"
+)
+
+// testTypes returns some named types of two packages with the same name, and
+// of the local package.
+func testTypes() (u types.Universe, foo, otherFoo, local *types.Type) {
+	u = types.Universe{}
+	foo = u.Type(types.Name{Package: modelPkg, Name: "Foo"})
+	foo.Kind = types.Struct
+	otherFoo = u.Type(types.Name{Package: "example.com/other/model", Name: "Foo"})
+	otherFoo.Kind = types.Struct
+	local = u.Type(types.Name{Package: localPkg, Name: "Request"})
+	local.Kind = types.Struct
+	return u, foo, otherFoo, local
+}
+
+var errorType = &types.Type{Name: types.Name{Name: "error"}, Kind: types.Interface}
+
+func anon(kind types.Kind, name string, elem *types.Type) *types.Type {
+	return &types.Type{Name: types.Name{Name: name}, Kind: kind, Elem: elem}
+}
+
+func TestRawNamer(t *testing.T) {
+	_, foo, otherFoo, local := testTypes()
+	tracker := NewImportTracker(localPkg)
+	raw := NewRawNamer(localPkg, tracker)
+
+	ptr := anon(types.Pointer, "", foo)
+	m := &types.Type{Kind: types.Map, Key: types.String, Elem: anon(types.Slice, "", otherFoo)}
+	fn := &types.Type{Kind: types.Func, Signature: &types.Signature{
+		Parameters: []*types.Type{local, anon(types.Slice, "", types.String)},
+		Results:    []*types.Type{ptr, errorType},
+		Variadic:   true,
+	}}
+	st := &types.Type{Kind: types.Struct, Members: []types.Member{
+		{Name: "A", Type: types.Int, Tags: `json:"a"`},
+		{Name: "Foo", Type: foo, Embedded: true},
+	}}
+	iface := &types.Type{Kind: types.Interface, Methods: map[string]*types.Type{
+		"Get": {Kind: types.Func, Signature: &types.Signature{MethodName: "Get", Results: []*types.Type{foo}}},
+	}}
+
+	assert.Equal(t, "Request", raw.Name(local))
+	assert.Equal(t, "*model.Foo", raw.Name(ptr))
+	assert.Equal(t, "map[string][]othermodel.Foo", raw.Name(m))
+	assert.Equal(t, "[4]byte", raw.Name(anon(types.Array, "[4]uint8", types.Byte)))
+	assert.Equal(t, "<-chan *model.Foo", raw.Name(anon(types.Chan, "<-chan *"+modelPkg+".Foo", ptr)))
+	assert.Equal(t, "chan int", raw.Name(anon(types.Chan, "chan int", types.Int)))
+	assert.Equal(t, "func(Request, ...string) (*model.Foo, error)", raw.Name(fn))
+	assert.Equal(t, "struct{ A int `json:\"a\"`; model.Foo }", raw.Name(st))
+	assert.Equal(t, "interface{ Get() model.Foo }", raw.Name(iface))
+	assert.Equal(t, "interface{}", raw.Name(&types.Type{Kind: types.Interface}))
+
+	assert.Equal(t, []string{`"example.com/app/model"`, `othermodel "example.com/other/model"`}, tracker.ImportLines())
+
+	// Without a tracker, types are qualified with their import path.
+	assert.Equal(t, "*example.com/app/model.Foo", NewRawNamer(localPkg, nil).Name(ptr))
+}
+
+func TestImportTracker(t *testing.T) {
+	u, foo, otherFoo, local := testTypes()
+	u.Package(modelPkg).Name = "models"
+
+	tracker := NewUniverseImportTracker(u, localPkg, anon(types.Pointer, "", foo), local)
+	tracker.Reserve("model")
+	tracker.AddTypes(otherFoo)
+	for _, p := range []string{"gopkg.in/yaml.v2", "errors", "github.com/pkg/errors", "github.com/foo/bar/v2", "example.com/go-type", "k8s.io/api/core/v1"} {
+		tracker.AddPath(p)
+	}
+
+	assert.Equal(t, "models", tracker.LocalNameOf(modelPkg))
+	assert.Equal(t, "othermodel", tracker.LocalNameOf("example.com/other/model"))
+	assert.Equal(t, "", tracker.LocalNameOf(localPkg))
+	p, ok := tracker.PathOf("pkgerrors")
+	assert.True(t, ok)
+	assert.Equal(t, "github.com/pkg/errors", p)
+	_, ok = tracker.PathOf("nope")
+	assert.False(t, ok)
+
+	// Names which are not the last element of the path are explicit.
+	assert.Equal(t, `import (
+	"errors"
+	models "example.com/app/model"
+	examplecomtype "example.com/go-type"
+	othermodel "example.com/other/model"
+	bar "github.com/foo/bar/v2"
+	pkgerrors "github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+	core "k8s.io/api/core/v1"
+)
+`, tracker.ImportBlock())
+	assert.Equal(t, "", NewImportTracker(localPkg).ImportBlock())
+}
+
+func TestImportTrackerMajorVersion(t *testing.T) {
+	u := types.Universe{}
+	u.Package("k8s.io/api/core/v1").Name = "v1"
+	u.Package("k8s.io/api/apps/v1").Name = "apps"
+
+	tracker := NewUniverseImportTracker(u, localPkg)
+	assert.Equal(t, "v1", tracker.AddPath("k8s.io/api/core/v1"))
+	assert.Equal(t, "apps", tracker.AddPath("k8s.io/api/apps/v1"))
+	assert.Equal(t, "bar", tracker.AddPath("github.com/foo/bar/v2"))
+	assert.Equal(t, []string{`bar "github.com/foo/bar/v2"`, `apps "k8s.io/api/apps/v1"`, `"k8s.io/api/core/v1"`}, tracker.ImportLines())
+}
+
+func TestImportTrackerNumbers(t *testing.T) {
+	tracker := NewImportTracker(localPkg)
+	assert.Equal(t, "model", tracker.AddPath("a/b/c/model"))
+	assert.Equal(t, "cmodel", tracker.AddPath("x/y/c/model"))
+	assert.Equal(t, "ycmodel", tracker.AddPath("z/y/c/model"))
+	assert.Equal(t, "model2", tracker.AddPath("x/z/y/c/model"))
+	assert.Equal(t, "model3", tracker.AddPath("model"))
+}
+
+func TestDefaultPackageName(t *testing.T) {
+	for path, name := range map[string]string{
+		"fmt":                        "fmt",
+		"github.com/foo/bar/v2":      "bar",
+		"gopkg.in/yaml.v2":           "yaml",
+		"github.com/mattn/go-isatty": "isatty",
+		"example.com/my-pkg":         "mypkg",
+		"example.com/2d":             "_2d",
+	} {
+		assert.Equal(t, name, DefaultPackageName(path), path)
+	}
+}
+
+func TestNameStrategy(t *testing.T) {
+	_, foo, _, _ := testTypes()
+	v1Pod := &types.Type{Name: types.Name{Package: "k8s.io/api/core/v1", Name: "Pod"}, Kind: types.Struct}
+	m := &types.Type{Kind: types.Map, Key: types.String, Elem: anon(types.Slice, "", anon(types.Pointer, "", foo))}
+	fn := &types.Type{Kind: types.Func, Signature: &types.Signature{
+		Parameters: []*types.Type{types.Int},
+		Results:    []*types.Type{errorType},
+	}}
+
+	public := NewPublicNamer(0)
+	assert.Equal(t, "Foo", public.Name(foo))
+	assert.Equal(t, "String", public.Name(types.String))
+	assert.Equal(t, "MapStringToSlicePointerFoo", public.Name(m))
+	assert.Equal(t, "FuncIntToError", public.Name(fn))
+	assert.Equal(t, "CoreV1Pod", NewPublicNamer(2).Name(v1Pod))
+	assert.Equal(t, "ApiV1Pod", NewPublicNamer(2, "core").Name(v1Pod))
+
+	private := NewPrivateNamer(0)
+	assert.Equal(t, "foo", private.Name(foo))
+	assert.Equal(t, "mapStringToSlicePointerFoo", private.Name(m))
+	assert.Equal(t, "v1Pod", NewPrivateNamer(1).Name(v1Pod))
+
+	custom := &NameStrategy{Prefix: "deepCopy", Suffix: "Into", Join: IL}
+	assert.Equal(t, "deepCopyFooInto", custom.Name(foo))
+	custom.Names = map[*types.Type]string{foo: "copyFoo"}
+	assert.Equal(t, "copyFoo", custom.Name(foo))
+}
+
+func TestIL(t *testing.T) {
+	assert.Equal(t, "httpServer", IL("", []string{"HTTPServer"}, ""))
+	assert.Equal(t, "id", IL("", []string{"ID"}, ""))
+	assert.Equal(t, "fooBar", IL("", []string{"foo", "bar"}, ""))
+	assert.Equal(t, "", IL("", nil, ""))
+}
+
+func TestDefaultNameSystems(t *testing.T) {
+	_, foo, _, _ := testTypes()
+	tracker := NewImportTracker(localPkg)
+	ns := DefaultNameSystems(localPkg, tracker)
+	ptr := anon(types.Pointer, "", foo)
+	assert.Equal(t, "*model.Foo", ns["raw"].Name(ptr))
+	assert.Equal(t, "PointerFoo", ns["public"].Name(ptr))
+	assert.Equal(t, "pointerFoo", ns["private"].Name(ptr))
+	assert.Equal(t, []string{`"example.com/app/model"`}, tracker.ImportLines())
+}
+
+func TestRawNamerParsed(t *testing.T) {
+	u := parsertest.Universe(t)
+	const a1 = "github.com/zhaolion/gen/parser/testpkg/a1"
+	service, ok := u.LookupType(types.Name{Package: a1, Name: "Service"})
+	assert.True(t, ok)
+
+	tracker := NewUniverseImportTracker(u, a1)
+	raw := NewRawNamer(a1, tracker)
+	assert.Equal(t, "func(context.Context) (*model.Foo, error)", raw.Name(service.Methods["Foo"]))
+	assert.Equal(t, "Service", raw.Name(service))
+	assert.Equal(t, []string{`"context"`, `"github.com/zhaolion/gen/parser/testpkg/model"`}, tracker.ImportLines())
+
+	tracker = NewUniverseImportTracker(u, "example.com/out", service)
+	assert.Equal(t, "a1.Service", NewRawNamer("example.com/out", tracker).Name(service))
+	assert.Equal(t, []string{`"github.com/zhaolion/gen/parser/testpkg/a1"`}, tracker.ImportLines())
+}
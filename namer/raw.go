@@ -0,0 +1,162 @@
+package namer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/zhaolion/gen/protobuf"
+	"github.com/zhaolion/gen/types"
+)
+
+// NewRawNamer returns a Namer which names types the way Go code in the
+// package with import path pkg refers to them, e.g. "*v1.Pod" or
+// "map[string][]byte". The packages of the named types are added to tracker;
+// if tracker is nil, named types of other packages are qualified with their
+// full import path instead.
+func NewRawNamer(pkg string, tracker *ImportTracker) Namer {
+	return &rawNamer{pkg: pkg, tracker: tracker}
+}
+
+type rawNamer struct {
+	pkg     string
+	tracker *ImportTracker
+}
+
+// Name returns the Go expression of t.
+func (r *rawNamer) Name(t *types.Type) string {
+	if t == nil {
+		return ""
+	}
+	if t.Kind == types.Protobuf {
+		return r.qualify(t.Name.Path, protobuf.GoName(t))
+	}
+	if isNamed(t) {
+		return r.qualify(t.Name.Package, t.Name.Name)
+	}
+	switch t.Kind {
+	case types.Pointer:
+		return "*" + r.Name(t.Elem)
+	case types.Slice:
+		return "[]" + r.Name(t.Elem)
+	case types.Array:
+		return arrayPrefix(t) + r.Name(t.Elem)
+	case types.Map:
+		return "map[" + r.Name(t.Key) + "]" + r.Name(t.Elem)
+	case types.Chan:
+		return chanPrefix(t) + r.Name(t.Elem)
+	case types.Func:
+		return "func" + r.signature(t.Signature)
+	case types.Struct:
+		return r.structType(t)
+	case types.Interface:
+		return r.interfaceType(t)
+	case types.DeclarationOf:
+		return r.qualify(t.Name.Package, t.Name.Name)
+	}
+	return t.Name.Name
+}
+
+func (r *rawNamer) qualify(pkg, name string) string {
+	if pkg == "" || pkg == r.pkg {
+		return name
+	}
+	if r.tracker == nil {
+		return pkg + "." + name
+	}
+	if local := r.tracker.LocalNameOf(pkg); local != "" {
+		return local + "." + name
+	}
+	return name
+}
+
+// signature returns the parameters and results of s, e.g. "(int, ...string)
+// (bool, error)".
+func (r *rawNamer) signature(s *types.Signature) string {
+	if s == nil {
+		return "()"
+	}
+	params := make([]string, len(s.Parameters))
+	for i, p := range s.Parameters {
+		if s.Variadic && i == len(s.Parameters)-1 && p.Kind == types.Slice {
+			params[i] = "..." + r.Name(p.Elem)
+		} else {
+			params[i] = r.Name(p)
+		}
+	}
+	out := "(" + strings.Join(params, ", ") + ")"
+	results := make([]string, len(s.Results))
+	for i, res := range s.Results {
+		results[i] = r.Name(res)
+	}
+	switch len(results) {
+	case 0:
+	case 1:
+		out += " " + results[0]
+	default:
+		out += " (" + strings.Join(results, ", ") + ")"
+	}
+	return out
+}
+
+func (r *rawNamer) structType(t *types.Type) string {
+	if len(t.Members) == 0 {
+		return "struct{}"
+	}
+	fields := make([]string, len(t.Members))
+	for i, m := range t.Members {
+		f := r.Name(m.Type)
+		if !m.Embedded {
+			f = m.Name + " " + f
+		}
+		if m.Tags != "" {
+			f += " `" + m.Tags + "`"
+		}
+		fields[i] = f
+	}
+	return "struct{ " + strings.Join(fields, "; ") + " }"
+}
+
+func (r *rawNamer) interfaceType(t *types.Type) string {
+	var elems []string
+	for _, e := range t.EmbeddedInterfaces {
+		elems = append(elems, r.Name(e))
+	}
+	methods := t.Methods
+	if t.ExplicitMethods != nil || len(t.EmbeddedInterfaces) > 0 {
+		methods = t.ExplicitMethods
+	}
+	names := make([]string, 0, len(methods))
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		elems = append(elems, name+r.signature(methods[name].Signature))
+	}
+	if len(elems) == 0 {
+		return "interface{}"
+	}
+	return "interface{ " + strings.Join(elems, "; ") + " }"
+}
+
+// arrayPrefix returns the "[N]" of an array type. The length is not stored in
+// Type, so it is taken from the type name.
+func arrayPrefix(t *types.Type) string {
+	if strings.HasPrefix(t.Name.Name, "[") {
+		if i := strings.Index(t.Name.Name, "]"); i > 0 {
+			return t.Name.Name[:i+1]
+		}
+	}
+	return "[...]"
+}
+
+// chanPrefix returns the "chan ", "<-chan " or "chan<- " of a chan type. The
+// direction is not stored in Type, so it is taken from the type name.
+func chanPrefix(t *types.Type) string {
+	for _, p := range []string{"<-chan ", "chan<- "} {
+		if strings.HasPrefix(t.Name.Name, p) {
+			return p
+		}
+	}
+	return "chan "
+}
@@ -0,0 +1,181 @@
+package namer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/zhaolion/gen/protobuf"
+	"github.com/zhaolion/gen/types"
+)
+
+// NameStrategy is a configurable Namer producing identifiers for types, e.g.
+// to name the functions generated for them: "PointerFoo",
+// "MapStringToSliceBar", "v1Pod".
+//
+// Named types use their name, optionally prefixed with the last directories
+// of their package. Other types are named after their structure, e.g. a map
+// is named "Map" + key + "To" + elem.
+type NameStrategy struct {
+	// Prefix and Suffix are added to every name.
+	Prefix, Suffix string
+
+	// Join combines the words of a name into an identifier, e.g.
+	// IC (InitialCaps) or IL (initialLower).
+	Join func(prefix string, words []string, suffix string) string
+
+	// PrependPackageNames is the number of trailing package path elements
+	// put before the names of named types, e.g. 2 turns k8s.io/api/core/v1.Pod
+	// into "CoreV1Pod".
+	PrependPackageNames int
+
+	// IgnoreWords are package path elements never put before names, e.g.
+	// "pkg" or "internal".
+	IgnoreWords map[string]bool
+
+	// Names overrides the names of some types; it is also filled with the
+	// names computed, as a cache.
+	Names map[*types.Type]string
+}
+
+// NewPublicNamer returns a NameStrategy producing exported identifiers.
+func NewPublicNamer(prependPackageNames int, ignoreWords ...string) *NameStrategy {
+	return newStrategy(IC, prependPackageNames, ignoreWords)
+}
+
+// NewPrivateNamer returns a NameStrategy producing unexported identifiers.
+func NewPrivateNamer(prependPackageNames int, ignoreWords ...string) *NameStrategy {
+	return newStrategy(IL, prependPackageNames, ignoreWords)
+}
+
+func newStrategy(join func(string, []string, string) string, prependPackageNames int, ignoreWords []string) *NameStrategy {
+	n := &NameStrategy{
+		Join:                join,
+		PrependPackageNames: prependPackageNames,
+		IgnoreWords:         map[string]bool{},
+		Names:               map[*types.Type]string{},
+	}
+	for _, w := range ignoreWords {
+		n.IgnoreWords[w] = true
+	}
+	return n
+}
+
+// IC joins words in InitialCaps.
+func IC(prefix string, words []string, suffix string) string {
+	all := append(append([]string{prefix}, words...), suffix)
+	var b strings.Builder
+	for _, w := range all {
+		b.WriteString(upper(w))
+	}
+	return b.String()
+}
+
+// IL joins words in initialLower. A leading acronym is lowered as a whole,
+// e.g. "HTTPServer" becomes "httpServer".
+func IL(prefix string, words []string, suffix string) string {
+	s := IC(prefix, words, suffix)
+	n := 0
+	for n < len(s) && s[n] >= 'A' && s[n] <= 'Z' {
+		n++
+	}
+	if n > 1 && n < len(s) && s[n] >= 'a' && s[n] <= 'z' {
+		n--
+	}
+	if n == 0 && s != "" {
+		n = 1
+	}
+	return strings.ToLower(s[:n]) + s[n:]
+}
+
+func upper(w string) string {
+	if w == "" {
+		return ""
+	}
+	return strings.ToUpper(w[:1]) + w[1:]
+}
+
+// Name returns the name of t.
+func (n *NameStrategy) Name(t *types.Type) string {
+	if n.Names == nil {
+		n.Names = map[*types.Type]string{}
+	}
+	if name, ok := n.Names[t]; ok {
+		return name
+	}
+	name := n.Join(n.Prefix, n.words(t), n.Suffix)
+	n.Names[t] = name
+	return name
+}
+
+// words returns the words of the name of t, without prefix and suffix.
+func (n *NameStrategy) words(t *types.Type) []string {
+	if t == nil {
+		return nil
+	}
+	if t.Kind == types.Protobuf {
+		return append(n.packageWords(t.Name.Path), protobuf.GoName(t))
+	}
+	if isNamed(t) || t.Kind == types.DeclarationOf {
+		return append(n.packageWords(t.Name.Package), t.Name.Name)
+	}
+	switch t.Kind {
+	case types.Builtin:
+		return []string{t.Name.Name}
+	case types.Pointer:
+		return append([]string{"Pointer"}, n.words(t.Elem)...)
+	case types.Slice:
+		return append([]string{"Slice"}, n.words(t.Elem)...)
+	case types.Array:
+		return append([]string{"Array"}, n.words(t.Elem)...)
+	case types.Chan:
+		return append([]string{"Chan"}, n.words(t.Elem)...)
+	case types.Map:
+		words := append([]string{"Map"}, n.words(t.Key)...)
+		return append(append(words, "To"), n.words(t.Elem)...)
+	case types.Func:
+		words := []string{"Func"}
+		if s := t.Signature; s != nil {
+			for _, p := range s.Parameters {
+				words = append(words, n.words(p)...)
+			}
+			if len(s.Results) > 0 {
+				words = append(words, "To")
+			}
+			for _, r := range s.Results {
+				words = append(words, n.words(r)...)
+			}
+		}
+		return words
+	case types.Struct:
+		words := []string{"Struct"}
+		for _, m := range t.Members {
+			words = append(words, m.Name)
+		}
+		return words
+	case types.Interface:
+		words := []string{"Interface"}
+		names := make([]string, 0, len(t.Methods))
+		for name := range t.Methods {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return append(words, names...)
+	}
+	return []string{t.Name.Name}
+}
+
+// packageWords returns the trailing elements of the package path to put
+// before a name.
+func (n *NameStrategy) packageWords(pkg string) []string {
+	if n.PrependPackageNames <= 0 || pkg == "" {
+		return nil
+	}
+	var words []string
+	dirs := strings.Split(pkg, "/")
+	for i := len(dirs) - 1; i >= 0 && len(words) < n.PrependPackageNames; i-- {
+		if w := sanitize(dirs[i]); w != "" && !n.IgnoreWords[dirs[i]] {
+			words = append([]string{w}, words...)
+		}
+	}
+	return words
+}
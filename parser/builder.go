@@ -17,6 +17,7 @@ limitations under the License.
 package parser
 
 import (
+	"bytes"
 	"fmt"
 	"go/ast"
 	"go/build"
@@ -33,6 +34,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"github.com/zhaolion/gen/protobuf"
 	"github.com/zhaolion/gen/types"
 )
 
@@ -68,9 +70,17 @@ type Builder struct {
 
 	// All comments from everywhere in every parsed file.
 	endLineToCommentGroup map[fileLine]*ast.CommentGroup
+	// The comments which follow code on the line they start on.
+	trailingComments map[*ast.CommentGroup]bool
 
 	// map of package to list of packages it imports.
 	importGraph map[importPathString]map[string]struct{}
+
+	// .proto files found in user requested directories, in the order they
+	// were added.
+	protoFiles []*protobuf.File
+	// map of package path to whether it has .proto files.
+	protoPackages map[importPathString]bool
 }
 
 // New constructs a new builder.
@@ -102,7 +112,9 @@ func New() *Builder {
 		absPaths:              map[importPathString]string{},
 		userRequested:         map[importPathString]bool{},
 		endLineToCommentGroup: map[fileLine]*ast.CommentGroup{},
+		trailingComments:      map[*ast.CommentGroup]bool{},
 		importGraph:           map[importPathString]map[string]struct{}{},
+		protoPackages:         map[importPathString]bool{},
 	}
 }
 
@@ -115,22 +127,27 @@ func (b *Builder) AddBuildTags(tags ...string) {
 	b.context.BuildTags = append(b.context.BuildTags, tags...)
 }
 
-// AddDir adds an entire directory, scanning it for go files. 'dir' should have
-// a single go package in it. GOPATH, GOROOT, and the location of your go
-// binary (`which go`) will all be searched if dir doesn't literally resolve.
+// AddDir adds an entire directory, scanning it for go and .proto files. 'dir'
+// should have a single go package in it, or only .proto files. GOPATH,
+// GOROOT, and the location of your go binary (`which go`) will all be
+// searched if dir doesn't literally resolve. .proto files which can't be
+// loaded are logged and skipped, use AddProtoFile to get the error.
 func (b *Builder) AddDir(dir string) error {
 	_, err := b.importPackage(dir, true)
 	return err
 }
 
 // AddDirRecursive is just like AddDir, but it also recursively adds
-// subdirectories; it returns an error only if the path couldn't be resolved;
-// any directories recursed into without go source are ignored.
+// subdirectories; it returns an error only if the path couldn't be resolved
+// or the root package fails to load; the root and any directories recursed
+// into without go source are ignored.
 func (b *Builder) AddDirRecursive(dir string) error {
 	// Add the root.
 	if _, err := b.importPackage(dir, true); err != nil {
+		if buildPkg := b.buildPackages[dir]; buildPkg == nil || len(buildPkg.GoFiles)+len(buildPkg.CgoFiles) > 0 {
+			return err
+		}
 		b.logger.Debugf("Ignoring directory %v: %v", dir, err)
-		return err
 	}
 
 	// filepath.Walk does not follow symlinks. We therefore evaluate symlinks and use that with
@@ -172,7 +189,8 @@ func (b *Builder) AddDirRecursive(dir string) error {
 }
 
 // FindTypes finalizes the package imports, and searches through all the
-// packages for types.
+// packages for types, then adds the messages, enums and services of the .proto
+// files found.
 func (b *Builder) FindTypes() (types.Universe, error) {
 	// Take a snapshot of pkgs to iterate, since this will recursively mutate
 	// b.parsed. Iterate in a predictable order.
@@ -188,9 +206,26 @@ func (b *Builder) FindTypes() (types.Universe, error) {
 			return nil, err
 		}
 	}
+	if err := protobuf.AddFiles(u, b.protoFiles...); err != nil {
+		return nil, err
+	}
 	return u, nil
 }
 
+// FindPackages returns the import paths of the packages added by the user,
+// as opposed to those only imported by them, sorted. Directories without go
+// or .proto files are left out.
+func (b *Builder) FindPackages() []string {
+	result := []string{}
+	for pkgPath, requested := range b.userRequested {
+		if requested && (len(b.parsed[pkgPath]) > 0 || b.protoPackages[pkgPath]) {
+			result = append(result, string(pkgPath))
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
 // importPackage is a function that will be called by the type check package when it
 // needs to import a go package. 'path' is the import path.
 func (b *Builder) importPackage(dir string, userRequested bool) (*tc.Package, error) {
@@ -233,6 +268,18 @@ func (b *Builder) importPackage(dir string, userRequested bool) (*tc.Package, er
 	// changed.
 	b.userRequested[pkgPath] = userRequested || b.userRequested[pkgPath]
 
+	// The package may have been added following an import before, so look
+	// for .proto files whenever it is requested.
+	if buildPkg := b.buildPackages[dir]; buildPkg != nil && userRequested {
+		if err := b.addProtoFiles(pkgPath, buildPkg.Dir); err != nil {
+			return nil, err
+		}
+	}
+	if _, found := b.parsed[pkgPath]; !found && b.protoPackages[pkgPath] {
+		// Nothing to type check in a directory with only .proto files.
+		return nil, nil
+	}
+
 	// Run the type checker.  We may end up doing this to pkgs that are already
 	// done, or are in the queue to be done later, but it will short-circuit,
 	// and we can't miss pkgs that are only depended on.
@@ -298,6 +345,48 @@ func (b *Builder) addDir(dir string, userRequested bool) error {
 	return nil
 }
 
+// addProtoFiles adds the .proto files in dir. Files which fail to parse,
+// e.g. because they use groups or editions, don't fail the go package they
+// sit next to, and are skipped.
+func (b *Builder) addProtoFiles(pkgPath importPathString, dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.proto"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := b.AddProtoFile(path); err != nil {
+			b.logger.Warningf("Skipping .proto file: %v", err)
+			continue
+		}
+		b.protoPackages[pkgPath] = true
+	}
+	return nil
+}
+
+// AddProtoFile parses a .proto file, to be added to the Universe by
+// FindTypes. See protobuf.AddFiles.
+func (b *Builder) AddProtoFile(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	for _, f := range b.protoFiles {
+		if f.Path == absPath {
+			return nil
+		}
+	}
+	b.logger.Debugf("AddProtoFile %s", absPath)
+	f, err := protobuf.ParseFile(absPath, nil)
+	if err != nil {
+		return fmt.Errorf("while parsing %q: %v", absPath, err)
+	}
+	if f.Package == "" {
+		return fmt.Errorf("while parsing %q: missing package statement", absPath)
+	}
+	b.protoFiles = append(b.protoFiles, f)
+	return nil
+}
+
 // addFile adds a file to the set. The pkgPath must be of the form
 // "canonical/pkg/path" and the path must be the absolute path to the file. A
 // flag indicates whether this file was user-requested or just from following
@@ -323,6 +412,11 @@ func (b *Builder) addFile(pkgPath importPathString, path string, src []byte, use
 	for _, c := range p.Comments {
 		position := b.fset.Position(c.End())
 		b.endLineToCommentGroup[fileLine{position.Filename, position.Line}] = c
+		offset := b.fset.Position(c.Pos()).Offset
+		lineStart := bytes.LastIndexByte(src[:offset], '\n') + 1
+		if len(bytes.TrimSpace(src[lineStart:offset])) > 0 {
+			b.trailingComments[c] = true
+		}
 	}
 
 	// We have to get the packages from this specific file, in case the
@@ -401,6 +495,15 @@ func (b *Builder) importBuildPackage(dir string) (*build.Package, error) {
 		}
 	}
 
+	// go/build leaves local imports (./pkg) as they are. Inside a module,
+	// resolve them to the real import path, so that a package added by
+	// directory and the same package reached through an import are one.
+	if build.IsLocalImport(buildPkg.ImportPath) {
+		if importPath, ok := moduleImportPath(buildPkg.Dir); ok {
+			buildPkg.ImportPath = importPath
+		}
+	}
+
 	// Remember it under the user-provided name.
 	b.logger.Debugf("saving buildPackage %s", dir)
 	b.buildPackages[dir] = buildPkg
@@ -480,6 +583,7 @@ func (b *Builder) findTypesIn(pkgPath importPathString, u *types.Universe) error
 		tn, ok := obj.(*tc.TypeName)
 		if ok {
 			t := b.walkType(*u, nil, tn.Type())
+			t.Position = b.position(obj.Pos())
 			c1 := b.priorCommentLines(obj.Pos(), 1)
 			// c1.Text() is safe if c1 is nil
 			t.CommentLines = splitLines(c1.Text())
@@ -493,6 +597,7 @@ func (b *Builder) findTypesIn(pkgPath importPathString, u *types.Universe) error
 		// We only care about functions, not concrete/abstract methods.
 		if ok && tf.Type() != nil && tf.Type().(*tc.Signature).Recv() == nil {
 			t := b.addFunction(*u, nil, tf)
+			t.Position = b.position(obj.Pos())
 			c1 := b.priorCommentLines(obj.Pos(), 1)
 			// c1.Text() is safe if c1 is nil
 			t.CommentLines = splitLines(c1.Text())
@@ -504,14 +609,26 @@ func (b *Builder) findTypesIn(pkgPath importPathString, u *types.Universe) error
 		}
 		tv, ok := obj.(*tc.Var)
 		if ok && !tv.IsField() {
-			b.addVariable(*u, nil, tv)
+			t := b.addVariable(*u, nil, tv)
+			t.Position = b.position(obj.Pos())
+			b.addDeclarationComments(t, obj.Pos())
 		}
 		tconst, ok := obj.(*tc.Const)
 		if ok {
-			b.addConstant(*u, nil, tconst)
+			t := b.addConstant(*u, nil, tconst)
+			t.Position = b.position(obj.Pos())
+			b.addDeclarationComments(t, obj.Pos())
 		}
 	}
 
+	for _, f := range b.parsed[pkgPath] {
+		b.addGroupComments(*u, pkgPath, f)
+	}
+
+	for _, f := range b.parsed[pkgPath] {
+		b.addFileInfo(*u, pkgPath, f)
+	}
+
 	importedPkgs := make([]string, 0)
 	for k := range b.importGraph[pkgPath] {
 		importedPkgs = append(importedPkgs, k)
@@ -541,11 +658,13 @@ func (b *Builder) walkType(u types.Universe, useName *types.Name, in tc.Type) *t
 		for i := 0; i < t.NumFields(); i++ {
 			f := t.Field(i)
 			m := types.Member{
-				Name:         f.Name(),
-				Embedded:     f.Anonymous(),
-				Tags:         t.Tag(i),
-				Type:         b.walkType(u, nil, f.Type()),
-				CommentLines: splitLines(b.priorCommentLines(f.Pos(), 1).Text()),
+				Name:                 f.Name(),
+				Embedded:             f.Anonymous(),
+				Tags:                 t.Tag(i),
+				Type:                 b.walkType(u, nil, f.Type()),
+				CommentLines:         splitLines(b.priorDocComment(f.Pos(), 1).Text()),
+				Position:             b.position(f.Pos()),
+				TrailingCommentLines: splitLines(b.trailingCommentLines(f.Pos()).Text()),
 			}
 			out.Members = append(out.Members, m)
 		}
@@ -611,7 +730,10 @@ func (b *Builder) walkType(u types.Universe, useName *types.Name, in tc.Type) *t
 			return out
 		}
 		out.Kind = types.Func
-		out.Signature = b.convertSignature(u, t)
+		// Set the signature before filling it in, since its types may refer
+		// back to out, e.g. through the receiver of a method.
+		out.Signature = &types.Signature{}
+		b.convertSignature(u, t, out.Signature)
 		return out
 	case *tc.Interface:
 		out := u.Type(name)
@@ -627,9 +749,22 @@ func (b *Builder) walkType(u types.Universe, useName *types.Name, in tc.Type) *t
 			method := t.Method(i)
 			name := tcNameToName(method.String())
 			mt := b.walkType(u, &name, method.Type())
-			mt.CommentLines = splitLines(b.priorCommentLines(method.Pos(), 1).Text())
+			mt.CommentLines = splitLines(b.priorDocComment(method.Pos(), 1).Text())
+			mt.TrailingCommentLines = splitLines(b.trailingCommentLines(method.Pos()).Text())
+			mt.Position = b.position(method.Pos())
+			mt.Signature.MethodName = method.Name()
 			out.Methods[method.Name()] = mt
 		}
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			if out.ExplicitMethods == nil {
+				out.ExplicitMethods = map[string]*types.Type{}
+			}
+			method := t.ExplicitMethod(i)
+			out.ExplicitMethods[method.Name()] = out.Methods[method.Name()]
+		}
+		for i := 0; i < t.NumEmbeddeds(); i++ {
+			out.EmbeddedInterfaces = append(out.EmbeddedInterfaces, b.walkType(u, nil, t.EmbeddedType(i)))
+		}
 		return out
 	case *tc.Named:
 		var out *types.Type
@@ -653,6 +788,7 @@ func (b *Builder) walkType(u types.Universe, useName *types.Name, in tc.Type) *t
 			}
 			out = b.walkType(u, &name, t.Underlying())
 		}
+		out.Position = b.position(t.Obj().Pos())
 		// If the underlying type didn't already add methods, add them.
 		// (Interface types will have already added methods.)
 		if len(out.Methods) == 0 {
@@ -664,6 +800,8 @@ func (b *Builder) walkType(u types.Universe, useName *types.Name, in tc.Type) *t
 				name := tcNameToName(method.String())
 				mt := b.walkType(u, &name, method.Type())
 				mt.CommentLines = splitLines(b.priorCommentLines(method.Pos(), 1).Text())
+				mt.Position = b.position(method.Pos())
+				mt.Signature.MethodName = method.Name()
 				out.Methods[method.Name()] = mt
 			}
 		}
@@ -686,8 +824,85 @@ func (b *Builder) priorCommentLines(pos token.Pos, lines int) *ast.CommentGroup
 	return b.endLineToCommentGroup[key]
 }
 
-func (b *Builder) convertSignature(u types.Universe, t *tc.Signature) *types.Signature {
-	signature := &types.Signature{}
+// priorDocComment is like priorCommentLines, but leaves out the trailing
+// comment of a previous line, e.g. of the previous constant in a group.
+func (b *Builder) priorDocComment(pos token.Pos, lines int) *ast.CommentGroup {
+	c := b.priorCommentLines(pos, lines)
+	if b.trailingComments[c] {
+		return nil
+	}
+	return c
+}
+
+// addDeclarationComments attaches the comments around the declaration at pos
+// to t.
+func (b *Builder) addDeclarationComments(t *types.Type, pos token.Pos) {
+	c1 := b.priorDocComment(pos, 1)
+	// c1.Text() is safe if c1 is nil
+	t.CommentLines = splitLines(c1.Text())
+	if c1 == nil {
+		t.SecondClosestCommentLines = splitLines(b.priorDocComment(pos, 2).Text())
+	} else {
+		t.SecondClosestCommentLines = splitLines(b.priorDocComment(c1.List[0].Slash, 2).Text())
+	}
+	t.TrailingCommentLines = splitLines(b.trailingCommentLines(pos).Text())
+}
+
+// addGroupComments attaches the doc comment of parenthesized declaration
+// groups in f to the types, variables and constants declared in them.
+func (b *Builder) addGroupComments(u types.Universe, pkgPath importPathString, f parsedFile) {
+	pkg := u.Package(string(pkgPath))
+	for _, decl := range f.file.Decls {
+		d, ok := decl.(*ast.GenDecl)
+		if !ok || !d.Lparen.IsValid() {
+			continue
+		}
+		lines := splitLines(d.Doc.Text())
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				pkg.Type(s.Name.Name).GroupCommentLines = lines
+			case *ast.ValueSpec:
+				for _, n := range s.Names {
+					if n.Name == "_" {
+						continue
+					}
+					if d.Tok == token.CONST {
+						pkg.Constant(n.Name).GroupCommentLines = lines
+					} else {
+						pkg.Variable(n.Name).GroupCommentLines = lines
+					}
+				}
+			}
+		}
+	}
+}
+
+// if there's a comment starting after pos on the same line, return it.
+func (b *Builder) trailingCommentLines(pos token.Pos) *ast.CommentGroup {
+	position := b.fset.Position(pos)
+	c := b.endLineToCommentGroup[fileLine{position.Filename, position.Line}]
+	if c == nil || c.Pos() < pos || b.fset.Position(c.Pos()).Line != position.Line {
+		return nil
+	}
+	return c
+}
+
+// position converts pos to a types.Position. It is not valid if pos is unknown.
+func (b *Builder) position(pos token.Pos) types.Position {
+	if !pos.IsValid() {
+		return types.Position{}
+	}
+	p := b.fset.Position(pos)
+	return types.Position{
+		Filename: p.Filename,
+		Offset:   p.Offset,
+		Line:     p.Line,
+		Column:   p.Column,
+	}
+}
+
+func (b *Builder) convertSignature(u types.Universe, t *tc.Signature, signature *types.Signature) {
 	for i := 0; i < t.Params().Len(); i++ {
 		signature.Parameters = append(signature.Parameters, b.walkType(u, nil, t.Params().At(i).Type()))
 	}
@@ -696,9 +911,9 @@ func (b *Builder) convertSignature(u types.Universe, t *tc.Signature) *types.Sig
 	}
 	if r := t.Recv(); r != nil {
 		signature.Receiver = b.walkType(u, nil, r.Type())
+		_, signature.PointerReceiver = r.Type().(*tc.Pointer)
 	}
 	signature.Variadic = t.Variadic()
-	return signature
 }
 
 func (b *Builder) addFunction(u types.Universe, useName *types.Name, in *tc.Func) *types.Type {
@@ -733,3 +948,68 @@ func (b *Builder) addConstant(u types.Universe, useName *types.Name, in *tc.Cons
 	out.Underlying = b.walkType(u, nil, in.Type())
 	return out
 }
+
+// addFileInfo records the file-level information of a parsed file: its build
+// constraints, package doc, imports and the package-level objects it declares.
+func (b *Builder) addFileInfo(u types.Universe, pkgPath importPathString, f parsedFile) *types.File {
+	pkg := u.Package(string(pkgPath))
+	out := pkg.File(f.name)
+	// findTypesIn might be called multiple times, start from scratch.
+	out.BuildConstraints = nil
+	out.Imports = nil
+	out.Objects = nil
+
+	for _, cg := range f.file.Comments {
+		if cg.Pos() >= f.file.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if isBuildConstraint(c.Text) {
+				out.BuildConstraints = append(out.BuildConstraints, c.Text)
+			}
+		}
+	}
+	if f.file.Doc != nil {
+		out.DocComments = splitLines(f.file.Doc.Text())
+	}
+
+	for _, im := range f.file.Imports {
+		importedPath := string(canonicalizeImportPath(strings.Trim(im.Path.Value, `"`)))
+		i := &types.Import{
+			Path:    importedPath,
+			Package: u.Package(importedPath),
+		}
+		if im.Name != nil {
+			i.Name = im.Name.Name
+		}
+		out.Imports = append(out.Imports, i)
+	}
+
+	for _, decl := range f.file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil && d.Name.Name != "_" && d.Name.Name != "init" {
+				out.Objects = append(out.Objects, pkg.Function(d.Name.Name))
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					out.Objects = append(out.Objects, pkg.Type(s.Name.Name))
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						if n.Name == "_" {
+							continue
+						}
+						if d.Tok == token.CONST {
+							out.Objects = append(out.Objects, pkg.Constant(n.Name))
+						} else {
+							out.Objects = append(out.Objects, pkg.Variable(n.Name))
+						}
+					}
+				}
+			}
+		}
+	}
+	return out
+}
@@ -1,9 +1,15 @@
 package parser
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/zhaolion/gen/protobuf"
+	"github.com/zhaolion/gen/types"
 )
 
 func TestBuilder(t *testing.T) {
@@ -17,3 +23,386 @@ func TestBuilder(t *testing.T) {
 	}
 	assert.NotEmpty(t, universe)
 }
+
+func TestBuilderFindPackages(t *testing.T) {
+	builder := New()
+	assert.NoError(t, builder.AddDir("./testpkg/a2"))
+	_, err := builder.FindTypes()
+	assert.NoError(t, err)
+	// a1 and model are only imported by a2.
+	assert.Equal(t, []string{"github.com/zhaolion/gen/parser/testpkg/a2"}, builder.FindPackages())
+
+	assert.NoError(t, builder.AddDirRecursive("./testpkg"))
+	assert.Equal(t, []string{
+		"github.com/zhaolion/gen/parser/testpkg/a1",
+		"github.com/zhaolion/gen/parser/testpkg/a2",
+		"github.com/zhaolion/gen/parser/testpkg/model",
+	}, builder.FindPackages())
+}
+
+func TestBuilderAddDirRecursiveError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parser")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "broken.go"), []byte("package broken\n\nfunc {\n"), 0644))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "sub", "sub.go"), []byte("package sub\n"), 0644))
+
+	builder := New()
+	assert.Error(t, builder.AddDirRecursive(dir))
+	// The subdirectories are not walked.
+	assert.Empty(t, builder.FindPackages())
+}
+
+func TestBuilderFiles(t *testing.T) {
+	universe := testUniverse(t)
+
+	pkg := universe.Package("github.com/zhaolion/gen/parser/testpkg/a2")
+	assert.Len(t, pkg.Files, 2)
+
+	var f *types.File
+	for _, file := range pkg.Files {
+		if file.Name() == "service.go" {
+			f = file
+		}
+	}
+	if f == nil {
+		t.Fatalf("service.go not found in %v", pkg.Files)
+	}
+	assert.Equal(t, pkg, f.Package)
+	assert.Equal(t, []string{"//go:build !gen_ignore", "// +build !gen_ignore"}, f.BuildConstraints)
+	assert.Equal(t, []string{"a2 testdata.a2"}, f.DocComments)
+
+	i := f.Import("github.com/zhaolion/gen/parser/testpkg/a1")
+	if assert.NotNil(t, i) {
+		assert.Equal(t, "svc", i.Name)
+		assert.Equal(t, "svc", i.LocalName())
+		assert.Equal(t, "a1", i.Package.Name)
+	}
+
+	if assert.Len(t, f.Objects, 2) {
+		assert.Equal(t, pkg.Variable("Service"), f.Objects[0])
+		assert.Equal(t, pkg.Function("NewService"), f.Objects[1])
+	}
+	assert.Equal(t, f, pkg.FileOf(pkg.Function("NewService")))
+	assert.Equal(t, "a2.go", pkg.FileOf(pkg.Type("Entry")).Name())
+}
+
+func TestBuilderOrder(t *testing.T) {
+	universe := testUniverse(t)
+
+	model := universe.Package("github.com/zhaolion/gen/parser/testpkg/model")
+	assert.Equal(t, []string{"Foo", "Bar", "Color", "Namer", "Describer"}, typeNames(model.OrderedTypes()))
+	assert.Equal(t, []string{"Bar", "Color", "Describer", "Foo", "Namer"}, typeNames(model.SortedTypes()))
+	assert.Equal(t, []string{"Describer", "Namer"}, typeNames(model.Interfaces()))
+	assert.Equal(t, []string{"Bar", "Foo"}, typeNames(model.Structs()))
+	assert.Equal(t, 4, model.Type("Foo").Position.Line)
+
+	foo := model.Type("Foo")
+	assert.Equal(t, 7, foo.Members[0].Position.Line)
+
+	entry := universe.Package("github.com/zhaolion/gen/parser/testpkg/a2").Type("Entry")
+	methods := entry.OrderedMethods()
+	if assert.Len(t, methods, 2) {
+		assert.True(t, methods[0].Position.Before(methods[1].Position))
+		assert.Equal(t, 16, methods[0].Position.Line)
+	}
+	assert.Equal(t, []string{"Bar", "Foo"}, entry.MethodNames())
+}
+
+func typeNames(ts []*types.Type) []string {
+	names := make([]string, 0, len(ts))
+	for _, t := range ts {
+		names = append(names, t.Name.Name)
+	}
+	return names
+}
+
+func TestBuilderValueComments(t *testing.T) {
+	universe := testUniverse(t)
+
+	model := universe.Package("github.com/zhaolion/gen/parser/testpkg/model")
+	red := model.Constant("Red")
+	assert.Equal(t, []string{"Red is red"}, red.CommentLines)
+	assert.Equal(t, []string{"Colors of foo"}, red.GroupCommentLines)
+
+	green := model.Constant("Green")
+	assert.Equal(t, []string{"green"}, green.TrailingCommentLines)
+	assert.Equal(t, []string{"Colors of foo"}, green.GroupCommentLines)
+
+	// The trailing comment of Green is not the doc of Blue.
+	blue := model.Constant("Blue")
+	assert.Equal(t, []string{""}, blue.CommentLines)
+	assert.Equal(t, []string{""}, blue.SecondClosestCommentLines)
+	assert.Equal(t, []string{""}, blue.TrailingCommentLines)
+
+	v := model.Variable("DefaultColor")
+	assert.Equal(t, []string{"DefaultColor of foo"}, v.CommentLines)
+	assert.Equal(t, []string{"the default"}, v.TrailingCommentLines)
+	assert.Nil(t, v.GroupCommentLines)
+}
+
+func testUniverse(t *testing.T) types.Universe {
+	builder := New()
+	if err := builder.AddDirRecursive("./testpkg"); err != nil {
+		t.Fatalf("invalid AddDirRecursive err: %+v", err)
+	}
+
+	universe, err := builder.FindTypes()
+	if err != nil {
+		t.Fatalf("invalid FindTypes err: %+v", err)
+	}
+	return universe
+}
+
+func TestBuilderTrailingComments(t *testing.T) {
+	universe := testUniverse(t)
+
+	model := universe.Package("github.com/zhaolion/gen/parser/testpkg/model")
+	bar := model.Type("Bar")
+	assert.Equal(t, []string{"a bool"}, bar.Members[0].TrailingCommentLines)
+	assert.Equal(t, []string{""}, bar.Members[1].CommentLines)
+	assert.Equal(t, []string{""}, bar.Members[1].TrailingCommentLines)
+
+	foo := model.Type("Foo")
+	assert.Equal(t, []string{"Name of foo", "@gen foo name"}, foo.Members[0].CommentLines)
+	assert.Equal(t, []string{""}, foo.Members[0].TrailingCommentLines)
+
+	method := model.Type("Namer").Methods["FullName"]
+	assert.Equal(t, []string{"FullName of the thing"}, method.CommentLines)
+	assert.Equal(t, []string{"with package"}, method.TrailingCommentLines)
+	next := model.Type("Namer").Methods["ShortName"]
+	assert.Equal(t, []string{""}, next.CommentLines)
+	assert.Equal(t, []string{""}, next.TrailingCommentLines)
+}
+
+func TestBuilderVisibility(t *testing.T) {
+	universe := testUniverse(t)
+
+	model := universe.Package("github.com/zhaolion/gen/parser/testpkg/model")
+	color := model.Type("Color")
+	assert.True(t, color.IsExported())
+	assert.False(t, types.String.IsExported())
+	assert.True(t, model.Type("Foo").Members[0].IsExported())
+
+	str, set := color.Methods["String"], color.Methods["set"]
+	assert.True(t, str.IsMethod())
+	assert.True(t, str.IsExported())
+	assert.False(t, str.Signature.PointerReceiver)
+	assert.False(t, set.IsExported())
+	assert.True(t, set.Signature.PointerReceiver)
+	assert.Equal(t, "set", set.Signature.MethodName)
+
+	assert.Equal(t, []string{"String"}, methodNames(color.ValueMethods()))
+	assert.Equal(t, []string{"String", "set"}, methodNames(color.PointerMethods()))
+
+	entry := universe.Package("github.com/zhaolion/gen/parser/testpkg/a1").Type("Entry")
+	assert.True(t, entry.Methods["Foo"].Signature.PointerReceiver)
+	assert.Empty(t, entry.MethodSet())
+	assert.Equal(t, []string{"Foo"}, methodNames(universe.Type(types.Name{Name: "*github.com/zhaolion/gen/parser/testpkg/a1.Entry"}).MethodSet()))
+
+	service := universe.Package("github.com/zhaolion/gen/parser/testpkg/a1").Type("Service")
+	assert.False(t, service.Methods["Foo"].Signature.PointerReceiver)
+	assert.Equal(t, []string{"Foo"}, methodNames(service.MethodSet()))
+}
+
+func methodNames(methods map[string]*types.Type) []string {
+	return (&types.Type{Methods: methods}).MethodNames()
+}
+
+func TestBuilderImplements(t *testing.T) {
+	universe := testUniverse(t)
+
+	a1 := universe.Package("github.com/zhaolion/gen/parser/testpkg/a1")
+	a2 := universe.Package("github.com/zhaolion/gen/parser/testpkg/a2")
+	service := a1.Type("Service")
+
+	assert.False(t, types.Implements(a1.Type("Entry"), service))
+	assert.True(t, types.Implements(a2.Type("Embedded"), service))
+	assert.Equal(t, []string{"Foo"}, methodNames(a2.Type("Embedded").FullMethodSet()))
+
+	var names []string
+	for _, i := range universe.Implementers(service) {
+		names = append(names, fmt.Sprintf("%s %v", i.Type, i.Pointer))
+	}
+	assert.Equal(t, []string{
+		"github.com/zhaolion/gen/parser/testpkg/a1.Entry true",
+		"github.com/zhaolion/gen/parser/testpkg/a2.Embedded false",
+		"github.com/zhaolion/gen/parser/testpkg/a2.Entry true",
+	}, names)
+
+	ifaces := universe.ImplementedInterfaces(a2.Type("Entry"))
+	if assert.Len(t, ifaces, 1) {
+		assert.Equal(t, service, ifaces[0].Interface)
+		assert.True(t, ifaces[0].Pointer)
+	}
+}
+
+func TestBuilderEmbeddedInterfaces(t *testing.T) {
+	universe := testUniverse(t)
+
+	model := universe.Package("github.com/zhaolion/gen/parser/testpkg/model")
+	describer, namer := model.Type("Describer"), model.Type("Namer")
+	assert.Equal(t, []string{"Describe", "FullName", "ShortName"}, describer.MethodNames())
+	assert.Equal(t, []string{"Describe"}, methodNames(describer.ExplicitMethods))
+	assert.Equal(t, []*types.Type{namer}, describer.EmbeddedInterfaces)
+	assert.Equal(t, describer, describer.MethodOrigin("Describe"))
+	assert.Equal(t, namer, describer.MethodOrigin("FullName"))
+	assert.Nil(t, describer.MethodOrigin("Missing"))
+
+	assert.Equal(t, []string{"FullName", "ShortName"}, methodNames(namer.ExplicitMethods))
+	assert.Empty(t, namer.EmbeddedInterfaces)
+}
+
+func TestBuilderStructAndInterfaceType(t *testing.T) {
+	universe := testUniverse(t)
+
+	model := universe.Package("github.com/zhaolion/gen/parser/testpkg/model")
+	foo, err := types.ToStructType(model.Type("Foo"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	m, ok := foo.FieldByTag("json", "tag")
+	assert.True(t, ok)
+	assert.Equal(t, "Name", m.Name)
+	_, ok = foo.Field("Missing")
+	assert.False(t, ok)
+	assert.Len(t, foo.Fields(types.ExportedFields, types.FieldsWithTag("json")), 1)
+	assert.Empty(t, foo.Fields(types.EmbeddedFields))
+	assert.True(t, foo.IsComparable())
+	assert.Equal(t, "Foo{}", foo.Type().ZeroValue())
+
+	bar, _ := types.ToStructType(model.Type("Bar"))
+	assert.Len(t, bar.Fields(types.FieldsOfKind(types.Builtin)), 18)
+	assert.Empty(t, bar.Fields(types.NotFields(types.FieldsOfKind(types.Builtin))))
+
+	embedded, _ := types.ToStructType(universe.Package("github.com/zhaolion/gen/parser/testpkg/a2").Type("Embedded"))
+	fields := embedded.AllFields()
+	assert.Len(t, fields, 1)
+	assert.Empty(t, embedded.Fields(types.NotFields(types.EmbeddedFields)))
+
+	assert.Equal(t, "0", model.Type("Color").ZeroValue())
+	assert.Equal(t, `""`, types.String.ZeroValue())
+	assert.Equal(t, "nil", model.Type("Namer").ZeroValue())
+
+	_, err = types.ToStructType(model.Type("Namer"))
+	assert.EqualError(t, err, "github.com/zhaolion/gen/parser/testpkg/model.Namer is an Interface, not a Struct")
+
+	_, err = types.ToInterfaceType(model.Type("Foo"))
+	assert.EqualError(t, err, "github.com/zhaolion/gen/parser/testpkg/model.Foo is a Struct, not an Interface")
+	_, err = types.ToStructType(nil)
+	assert.EqualError(t, err, "<nil> is of unknown kind, not a Struct")
+
+	describer, err := types.ToInterfaceType(model.Type("Describer"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, describer.Embeds(model.Type("Namer")))
+	assert.False(t, describer.IsEmpty())
+	_, ok = describer.Method("FullName")
+	assert.True(t, ok)
+	assert.Len(t, describer.OrderedMethods(), 3)
+	_, err = types.ToInterfaceType(model.Type("Foo"))
+	assert.Error(t, err)
+
+	service, _ := types.ToInterfaceType(universe.Package("github.com/zhaolion/gen/parser/testpkg/a1").Type("Service"))
+	assert.True(t, service.IsImplementedBy(embedded.Type()))
+}
+
+func TestBuilderMerge(t *testing.T) {
+	load := func(dir string) types.Universe {
+		builder := New()
+		if err := builder.AddDir(dir); err != nil {
+			t.Fatalf("invalid AddDir err: %+v", err)
+		}
+		universe, err := builder.FindTypes()
+		if err != nil {
+			t.Fatalf("invalid FindTypes err: %+v", err)
+		}
+		return universe
+	}
+	universe := load("./testpkg/a2")
+	assert.NoError(t, universe.Merge(load("./testpkg/a1")))
+
+	a1 := universe.Package("github.com/zhaolion/gen/parser/testpkg/a1")
+	a2 := universe.Package("github.com/zhaolion/gen/parser/testpkg/a2")
+	assert.NotEmpty(t, a1.Files)
+	assert.NotEmpty(t, a2.Files)
+	assert.Equal(t, a1.Type("Entry"), a2.Type("Entry").Members[0].Type)
+
+	subset := universe.Subset(a1.Path)
+	assert.Contains(t, subset, a1.Path)
+	assert.NotContains(t, subset, a2.Path)
+}
+
+func TestBuilderValidate(t *testing.T) {
+	universe := testUniverse(t)
+	// All basic types, rune and complex numbers included, are builtins.
+	assert.NoError(t, universe.Validate())
+	assert.NoError(t, universe.Validate("github.com/zhaolion/gen/parser/testpkg/a1"))
+}
+
+func TestBuilderProtobuf(t *testing.T) {
+	universe := testUniverse(t)
+
+	p := universe.Package("testpkg.model")
+	assert.Len(t, p.Files, 1)
+	assert.Equal(t, []string{"Foo", "Color", "GetFooRequest", "FooService"}, typeNames(p.Files[filepath.Join(p.SourcePath, "model.proto")].Objects))
+
+	foo := p.Type("Foo")
+	assert.Equal(t, types.Protobuf, foo.Kind)
+	assert.Equal(t, "testpkg.model.Foo", foo.String())
+	assert.Equal(t, []string{"Foo obj"}, foo.CommentLines)
+
+	goFoo, ok := protobuf.GoType(universe, foo)
+	assert.True(t, ok)
+	assert.Equal(t, universe.Package("github.com/zhaolion/gen/parser/testpkg/model").Type("Foo"), goFoo)
+	proto, ok := protobuf.ProtoType(universe, goFoo)
+	assert.True(t, ok)
+	assert.Equal(t, foo, proto)
+
+	_, ok = protobuf.GoType(universe, p.Type("GetFooRequest"))
+	assert.False(t, ok)
+}
+
+func TestBuilderProtobufSkipped(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parser")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"go.mod":        "module example.com/m\n",
+		"m.go":          "package m\n\ntype T struct{}\n",
+		"group.proto":   `syntax = "proto2"; package g; message M { optional group G = 1 { optional int32 a = 2; } }`,
+		"edition.proto": `edition = "2023"; package e; message M {}`,
+		"nopkg.proto":   `syntax = "proto3"; message M {}`,
+	}
+	for name, src := range files {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644))
+	}
+
+	// Absolute paths can't be imported, add the directory as "." instead.
+	cwd, err := os.Getwd()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.Chdir(cwd)
+	assert.NoError(t, os.Chdir(dir))
+
+	// .proto files next to go code which can't be loaded don't fail it.
+	builder := New()
+	assert.NoError(t, builder.AddDir("."))
+	universe, err := builder.FindTypes()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotNil(t, universe.Package("example.com/m").Types["T"])
+	assert.Equal(t, []string{"example.com/m"}, builder.FindPackages())
+
+	for _, name := range []string{"group.proto", "edition.proto", "nopkg.proto"} {
+		assert.Error(t, builder.AddProtoFile(filepath.Join(dir, name)), name)
+	}
+}
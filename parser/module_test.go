@@ -0,0 +1,44 @@
+package parser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModuleImportPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parser")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	sub := filepath.Join(dir, "pkg", "model")
+	assert.NoError(t, os.MkdirAll(sub, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("// The module.\nmodule \"example.com/m\"\n\ngo 1.14\n"), 0644))
+
+	p, ok := moduleImportPath(sub)
+	assert.True(t, ok)
+	assert.Equal(t, "example.com/m/pkg/model", p)
+	p, ok = moduleImportPath(dir)
+	assert.True(t, ok)
+	assert.Equal(t, "example.com/m", p)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("go 1.14\n"), 0644))
+	_, ok = moduleImportPath(sub)
+	assert.False(t, ok)
+}
+
+func TestBuilderModuleDir(t *testing.T) {
+	builder := New()
+	assert.NoError(t, builder.AddDir("./testpkg/a1"))
+	universe, err := builder.FindTypes()
+	if !assert.NoError(t, err) {
+		return
+	}
+	// The directory is known by its import path, not as "./testpkg/a1".
+	assert.Contains(t, universe, "github.com/zhaolion/gen/parser/testpkg/a1")
+	assert.NotContains(t, universe, "./testpkg/a1")
+}
@@ -0,0 +1,52 @@
+// Package parsertest loads the parser test fixtures for the tests of other
+// packages.
+package parsertest
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/zhaolion/gen/parser"
+	"github.com/zhaolion/gen/types"
+)
+
+// Universe loads the test fixtures, the packages below parser/testpkg, and
+// fails t if they can't be loaded.
+func Universe(t testing.TB) types.Universe {
+	t.Helper()
+	builder := parser.New()
+	if err := builder.AddDirRecursive(fixturesDir(t)); err != nil {
+		t.Fatalf("invalid AddDirRecursive err: %+v", err)
+	}
+	universe, err := builder.FindTypes()
+	if err != nil {
+		t.Fatalf("invalid FindTypes err: %+v", err)
+	}
+	return universe
+}
+
+// fixturesDir returns the fixtures directory relative to the working
+// directory of the test, since the builder can't import absolute paths, nor
+// the import path of a directory without go files.
+func fixturesDir(t testing.TB) string {
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		t.Fatal("unable to locate the test fixtures")
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("unable to get current directory: %v", err)
+	}
+	dir, err := filepath.Rel(cwd, filepath.Join(filepath.Dir(file), "..", "testpkg"))
+	if err != nil {
+		t.Fatalf("unable to locate the test fixtures: %v", err)
+	}
+	dir = filepath.ToSlash(dir)
+	if !strings.HasPrefix(dir, "../") {
+		dir = "./" + dir
+	}
+	return dir
+}
@@ -21,3 +21,8 @@ func (entry *Entry) Foo(ctx context.Context) (*model.Foo, error) {
 func (entry *Entry) Bar(ctx context.Context) (*model.Foo, error) {
 	return entry.A1.Foo(ctx)
 }
+
+// Embedded serves a1.Service through the embedded *a1.Entry
+type Embedded struct {
+	*a1.Entry
+}
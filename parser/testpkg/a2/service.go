@@ -0,0 +1,17 @@
+//go:build !gen_ignore
+// +build !gen_ignore
+
+// a2 testdata.a2
+package a2
+
+import (
+	svc "github.com/zhaolion/gen/parser/testpkg/a1"
+)
+
+// Service is the a1.Service served by a2
+var Service svc.Service = &Entry{}
+
+// NewService returns a new a1.Service
+func NewService() svc.Service {
+	return &Entry{}
+}
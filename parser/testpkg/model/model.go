@@ -8,7 +8,7 @@ type Foo struct {
 }
 
 type Bar struct {
-	Bool bool
+	Bool bool // a bool
 	Byte byte
 	I1   int
 	I2   int8
@@ -27,3 +27,41 @@ type Bar struct {
 	S    string
 	R    rune
 }
+
+// Color of foo
+type Color int
+
+// Colors of foo
+const (
+	// Red is red
+	Red   Color = iota
+	Green       // green
+	Blue
+)
+
+// String of the color
+func (c Color) String() string {
+	return [...]string{"red", "green", "blue"}[c]
+}
+
+// set the color by name
+func (c *Color) set(name string) {
+	*c = map[string]Color{"red": Red, "green": Green, "blue": Blue}[name]
+}
+
+// DefaultColor of foo
+var DefaultColor = Red // the default
+
+// Namer names things
+type Namer interface {
+	// FullName of the thing
+	FullName() string // with package
+	ShortName() string
+}
+
+// Describer describes things
+type Describer interface {
+	Namer
+	// Describe the thing
+	Describe() string
+}
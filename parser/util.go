@@ -3,6 +3,8 @@ package parser
 import (
 	"go/ast"
 	tc "go/types"
+	"io/ioutil"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -54,6 +56,11 @@ func isErrPackageNotFound(err error) bool {
 	return regexErrPackageNotFound.MatchString(err.Error())
 }
 
+// isBuildConstraint reports whether a comment is a build constraint line.
+func isBuildConstraint(comment string) bool {
+	return strings.HasPrefix(comment, "//go:build ") || strings.HasPrefix(comment, "// +build ")
+}
+
 func splitLines(str string) []string {
 	return strings.Split(strings.TrimRight(str, "\n"), "\n")
 }
@@ -97,6 +104,43 @@ func tcVarNameToName(in string) types.Name {
 	return tcNameToName(nameParts[1])
 }
 
+// moduleImportPath returns the import path of dir derived from the nearest
+// enclosing go.mod, if any.
+func moduleImportPath(dir string) (string, bool) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for root := abs; ; root = filepath.Dir(root) {
+		if data, err := ioutil.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+			modulePath := modulePathFromGoMod(data)
+			if modulePath == "" {
+				return "", false
+			}
+			rel, err := filepath.Rel(root, abs)
+			if err != nil {
+				return "", false
+			}
+			return path.Join(modulePath, filepath.ToSlash(rel)), true
+		}
+		if filepath.Dir(root) == root {
+			return "", false
+		}
+	}
+}
+
+// modulePathFromGoMod returns the path of the module directive in a go.mod
+// file, or "" if there is none.
+func modulePathFromGoMod(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
 func isInGitDir(path string) bool {
 	tokens := strings.Split(path, string(filepath.Separator))
 	for _, token := range tokens {
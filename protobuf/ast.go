@@ -0,0 +1,172 @@
+package protobuf
+
+import (
+	"strings"
+
+	"github.com/zhaolion/gen/types"
+)
+
+// File is a parsed .proto file.
+type File struct {
+	// Path of the file, as given to ParseFile.
+	Path string
+
+	// "proto2" or "proto3". Files without syntax statement are proto2.
+	Syntax string
+
+	// The package declared by the file, e.g. "example.v1".
+	Package string
+
+	// The comment right above the syntax statement, if any.
+	DocComments []string
+
+	Imports  []Import
+	Options  []Option
+	Messages []*Message
+	Enums    []*Enum
+	Services []*Service
+}
+
+// Option returns the value of the file option with the given name, e.g.
+// "go_package".
+func (f *File) Option(name string) (string, bool) {
+	return lookupOption(f.Options, name)
+}
+
+// GoPackage returns the import path and the package name of the Go code
+// generated for the file, as given by the go_package option, e.g.
+// "github.com/x/example/v1;examplev1". If the option has no explicit name,
+// the last element of the import path is used.
+func (f *File) GoPackage() (importPath, name string) {
+	opt, ok := f.Option("go_package")
+	if !ok {
+		return "", ""
+	}
+	if i := strings.LastIndex(opt, ";"); i >= 0 {
+		return opt[:i], opt[i+1:]
+	}
+	name = opt[strings.LastIndex(opt, "/")+1:]
+	return opt, strings.NewReplacer(".", "_", "-", "_").Replace(name)
+}
+
+// Import is an import statement.
+type Import struct {
+	// The imported file, e.g. "google/protobuf/timestamp.proto".
+	Path string
+	// "public", "weak" or "".
+	Modifier string
+}
+
+// Option is an option statement, or an option of a field or enum value.
+// Aggregate values are kept as written.
+type Option struct {
+	// The option name, e.g. "go_package" or "(validate.rules).string".
+	Name  string
+	Value string
+}
+
+func lookupOption(options []Option, name string) (string, bool) {
+	for _, o := range options {
+		if o.Name == name {
+			return o.Value, true
+		}
+	}
+	return "", false
+}
+
+// Message is a message definition.
+type Message struct {
+	Name string
+
+	// Comments right above the definition.
+	CommentLines []string
+	Position     types.Position
+
+	Fields   []*Field
+	Messages []*Message
+	Enums    []*Enum
+	Options  []Option
+}
+
+// Field is a field of a message.
+type Field struct {
+	Name string
+
+	// The type of the field as written, e.g. "string", "Foo" or
+	// ".example.v1.Foo". For map fields, the type of the values.
+	Type string
+
+	// For map fields, the type of the keys.
+	KeyType string
+
+	Number int
+
+	// "repeated", "optional", "required" or "".
+	Label string
+
+	// The oneof the field belongs to, if any.
+	OneOf string
+
+	CommentLines         []string
+	TrailingCommentLines []string
+	Position             types.Position
+
+	Options []Option
+}
+
+// IsMap returns true if f is a map field.
+func (f *Field) IsMap() bool {
+	return f.KeyType != ""
+}
+
+// Enum is an enum definition.
+type Enum struct {
+	Name string
+
+	CommentLines []string
+	Position     types.Position
+
+	Values  []*EnumValue
+	Options []Option
+}
+
+// EnumValue is a value of an enum.
+type EnumValue struct {
+	Name   string
+	Number int
+
+	CommentLines         []string
+	TrailingCommentLines []string
+	Position             types.Position
+
+	Options []Option
+}
+
+// Service is a service definition.
+type Service struct {
+	Name string
+
+	CommentLines []string
+	Position     types.Position
+
+	RPCs    []*RPC
+	Options []Option
+}
+
+// RPC is a method of a service.
+type RPC struct {
+	Name string
+
+	// The request and response message types as written.
+	Request  string
+	Response string
+
+	ClientStreaming bool
+	ServerStreaming bool
+
+	CommentLines         []string
+	TrailingCommentLines []string
+	Position             types.Position
+
+	Options []Option
+}
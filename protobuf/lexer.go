@@ -0,0 +1,241 @@
+package protobuf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zhaolion/gen/types"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokSymbol
+)
+
+type token struct {
+	kind tokenKind
+	// The text of the token. For strings, the unquoted value.
+	text string
+	pos  types.Position
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of file"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// comment is a group of adjacent line comments, or a block comment.
+type comment struct {
+	lines     []string
+	startLine int
+	endLine   int
+	// True if the comment follows a token on its first line.
+	trailing bool
+	// True for /* block */ comments.
+	block bool
+}
+
+// lex splits src into tokens and comments.
+func lex(filename string, src []byte) ([]token, []*comment, error) {
+	l := &lexer{filename: filename, src: string(src), line: 1, col: 1}
+	for {
+		t, err := l.next()
+		if err != nil {
+			return nil, nil, err
+		}
+		l.tokens = append(l.tokens, t)
+		if t.kind == tokEOF {
+			return l.tokens, l.comments, nil
+		}
+	}
+}
+
+type lexer struct {
+	filename string
+	src      string
+	offset   int
+	line     int
+	col      int
+
+	tokens   []token
+	comments []*comment
+	// Line of the last token, to tell trailing comments.
+	lastTokenLine int
+}
+
+func (l *lexer) position() types.Position {
+	return types.Position{Filename: l.filename, Offset: l.offset, Line: l.line, Column: l.col}
+}
+
+func (l *lexer) errorf(pos types.Position, format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d:%d: %s", l.filename, pos.Line, pos.Column, fmt.Sprintf(format, args...))
+}
+
+func (l *lexer) peek(n int) byte {
+	if i := l.offset + n; i >= 0 && i < len(l.src) {
+		return l.src[l.offset+n]
+	}
+	return 0
+}
+
+func (l *lexer) advance(n int) {
+	for i := 0; i < n && l.offset < len(l.src); i++ {
+		if l.src[l.offset] == '\n' {
+			l.line++
+			l.col = 1
+		} else {
+			l.col++
+		}
+		l.offset++
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	for {
+		for l.offset < len(l.src) && strings.IndexByte(" \t\r\n\f\v", l.src[l.offset]) >= 0 {
+			l.advance(1)
+		}
+		if l.peek(0) != '/' || (l.peek(1) != '/' && l.peek(1) != '*') {
+			break
+		}
+		if err := l.comment(); err != nil {
+			return token{}, err
+		}
+	}
+
+	pos := l.position()
+	if l.offset >= len(l.src) {
+		return token{kind: tokEOF, pos: pos}, nil
+	}
+	l.lastTokenLine = pos.Line
+
+	c := l.peek(0)
+	switch {
+	case isLetter(c):
+		start := l.offset
+		for isLetter(l.peek(0)) || isDigit(l.peek(0)) {
+			l.advance(1)
+		}
+		return token{kind: tokIdent, text: l.src[start:l.offset], pos: pos}, nil
+	case isDigit(c) || (c == '.' && isDigit(l.peek(1))):
+		start := l.offset
+		for isLetter(l.peek(0)) || isDigit(l.peek(0)) || l.peek(0) == '.' ||
+			((l.peek(0) == '-' || l.peek(0) == '+') && (l.peek(-1) == 'e' || l.peek(-1) == 'E') && !strings.HasPrefix(strings.ToLower(l.src[start:l.offset]), "0x")) {
+			l.advance(1)
+		}
+		return token{kind: tokNumber, text: l.src[start:l.offset], pos: pos}, nil
+	case c == '"' || c == '\'':
+		s, err := l.string(c)
+		if err != nil {
+			return token{}, err
+		}
+		return token{kind: tokString, text: s, pos: pos}, nil
+	}
+	l.advance(1)
+	return token{kind: tokSymbol, text: string(c), pos: pos}, nil
+}
+
+func (l *lexer) comment() error {
+	pos := l.position()
+	trailing := l.lastTokenLine == pos.Line && len(l.tokens) > 0
+	if l.peek(1) == '/' {
+		end := strings.IndexByte(l.src[l.offset:], '\n')
+		if end < 0 {
+			end = len(l.src) - l.offset
+		}
+		text := commentText(l.src[l.offset+2 : l.offset+end])
+		l.advance(end)
+		// Merge with the previous line comment on the line above.
+		if n := len(l.comments); n > 0 && !trailing {
+			prev := l.comments[n-1]
+			if prev.endLine == pos.Line-1 && !prev.trailing && !prev.block {
+				prev.lines = append(prev.lines, text)
+				prev.endLine = pos.Line
+				return nil
+			}
+		}
+		l.comments = append(l.comments, &comment{lines: []string{text}, startLine: pos.Line, endLine: pos.Line, trailing: trailing})
+		return nil
+	}
+
+	end := strings.Index(l.src[l.offset+2:], "*/")
+	if end < 0 {
+		return l.errorf(pos, "comment not terminated")
+	}
+	body := l.src[l.offset+2 : l.offset+2+end]
+	l.advance(end + 4)
+	var lines []string
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+		lines = append(lines, line)
+	}
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	l.comments = append(l.comments, &comment{lines: lines, startLine: pos.Line, endLine: l.line, trailing: trailing, block: true})
+	return nil
+}
+
+// commentText strips the leading space of a line comment, like
+// go/ast.CommentGroup.Text does.
+func commentText(s string) string {
+	s = strings.TrimRight(s, " \t\r")
+	return strings.TrimPrefix(s, " ")
+}
+
+func (l *lexer) string(quote byte) (string, error) {
+	pos := l.position()
+	l.advance(1)
+	var b strings.Builder
+	for {
+		c := l.peek(0)
+		switch {
+		case l.offset >= len(l.src) || c == '\n':
+			return "", l.errorf(pos, "string not terminated")
+		case c == quote:
+			l.advance(1)
+			return b.String(), nil
+		case c == '\\':
+			e := l.peek(1)
+			switch e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '0':
+				b.WriteByte(0)
+			default:
+				// Quotes, backslashes and escapes we don't interpret.
+				if e != '"' && e != '\'' && e != '\\' {
+					b.WriteByte('\\')
+				}
+				b.WriteByte(e)
+			}
+			l.advance(2)
+		default:
+			b.WriteByte(c)
+			l.advance(1)
+		}
+	}
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
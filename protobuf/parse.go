@@ -0,0 +1,634 @@
+package protobuf
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// ParseFile parses the .proto file at path. If src is nil, the file is read
+// from disk. Both proto2 and proto3 syntax are supported, except for groups.
+// Extensions and extend blocks are skipped.
+func ParseFile(path string, src []byte) (*File, error) {
+	if src == nil {
+		var err error
+		if src, err = ioutil.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	tokens, comments, err := lex(path, src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{filename: path, tokens: tokens, comments: map[int]*comment{}, trailing: map[int]*comment{}}
+	for _, c := range comments {
+		if c.trailing {
+			p.trailing[c.startLine] = c
+		} else {
+			p.comments[c.endLine] = c
+		}
+	}
+	return p.file()
+}
+
+type parser struct {
+	filename string
+	tokens   []token
+	i        int
+
+	// Comments on their own lines, by their last line.
+	comments map[int]*comment
+	// Comments following a token, by their line.
+	trailing map[int]*comment
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// last returns the token consumed last.
+func (p *parser) last() token {
+	return p.tokens[p.i-1]
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d:%d: %s", p.filename, t.pos.Line, t.pos.Column, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) is(text string) bool {
+	t := p.peek()
+	return (t.kind == tokSymbol || t.kind == tokIdent) && t.text == text
+}
+
+func (p *parser) accept(text string) bool {
+	if p.is(text) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if t := p.next(); (t.kind != tokSymbol && t.kind != tokIdent) || t.text != text {
+		return p.errorf(t, "expected %q, found %s", text, t)
+	}
+	return nil
+}
+
+func (p *parser) ident() (string, error) {
+	t := p.next()
+	if t.kind != tokIdent {
+		return "", p.errorf(t, "expected identifier, found %s", t)
+	}
+	return t.text, nil
+}
+
+// fullIdent parses a dotted name, with an optional leading dot.
+func (p *parser) fullIdent() (string, error) {
+	var b strings.Builder
+	if p.accept(".") {
+		b.WriteString(".")
+	}
+	for {
+		s, err := p.ident()
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(s)
+		if !p.accept(".") {
+			return b.String(), nil
+		}
+		b.WriteString(".")
+	}
+}
+
+func (p *parser) str() (string, error) {
+	t := p.next()
+	if t.kind != tokString {
+		return "", p.errorf(t, "expected string, found %s", t)
+	}
+	s := t.text
+	for p.peek().kind == tokString {
+		s += p.next().text
+	}
+	return s, nil
+}
+
+func (p *parser) integer() (int, error) {
+	t := p.next()
+	sign := ""
+	if t.kind == tokSymbol && (t.text == "-" || t.text == "+") {
+		sign, t = t.text, p.next()
+	}
+	if t.kind != tokNumber {
+		return 0, p.errorf(t, "expected integer, found %s", t)
+	}
+	n, err := strconv.ParseInt(sign+t.text, 0, 32)
+	if err != nil {
+		return 0, p.errorf(t, "invalid integer %s", t)
+	}
+	return int(n), nil
+}
+
+// commentLines returns the comment lines right above t.
+func (p *parser) commentLines(t token) []string {
+	if c, ok := p.comments[t.pos.Line-1]; ok {
+		return c.lines
+	}
+	return nil
+}
+
+// trailingCommentLines returns the comment following the last token on its
+// line.
+func (p *parser) trailingCommentLines() []string {
+	if c, ok := p.trailing[p.last().pos.Line]; ok {
+		return c.lines
+	}
+	return nil
+}
+
+func (p *parser) file() (*File, error) {
+	f := &File{Path: p.filename, Syntax: "proto2"}
+	if p.is("syntax") {
+		f.DocComments = p.commentLines(p.peek())
+	}
+	for p.peek().kind != tokEOF {
+		t := p.peek()
+		switch {
+		case p.accept(";"):
+		case p.accept("syntax"):
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			s, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			if s != "proto2" && s != "proto3" {
+				return nil, p.errorf(t, "unsupported syntax %q", s)
+			}
+			f.Syntax = s
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case p.accept("package"):
+			name, err := p.fullIdent()
+			if err != nil {
+				return nil, err
+			}
+			f.Package = name
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case p.accept("import"):
+			i := Import{}
+			if p.is("public") || p.is("weak") {
+				i.Modifier = p.next().text
+			}
+			s, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			i.Path = s
+			f.Imports = append(f.Imports, i)
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case p.is("option"):
+			o, err := p.optionStatement()
+			if err != nil {
+				return nil, err
+			}
+			f.Options = append(f.Options, o)
+		case p.is("message"):
+			m, err := p.message()
+			if err != nil {
+				return nil, err
+			}
+			f.Messages = append(f.Messages, m)
+		case p.is("enum"):
+			e, err := p.enum()
+			if err != nil {
+				return nil, err
+			}
+			f.Enums = append(f.Enums, e)
+		case p.is("service"):
+			s, err := p.service()
+			if err != nil {
+				return nil, err
+			}
+			f.Services = append(f.Services, s)
+		case p.is("extend"):
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, p.errorf(t, "unexpected %s", t)
+		}
+	}
+	return f, nil
+}
+
+// optionStatement parses `option name = value;`.
+func (p *parser) optionStatement() (Option, error) {
+	if err := p.expect("option"); err != nil {
+		return Option{}, err
+	}
+	o, err := p.option()
+	if err != nil {
+		return Option{}, err
+	}
+	return o, p.expect(";")
+}
+
+// option parses `name = value`.
+func (p *parser) option() (Option, error) {
+	var name strings.Builder
+	for {
+		if p.accept("(") {
+			n, err := p.fullIdent()
+			if err != nil {
+				return Option{}, err
+			}
+			if err := p.expect(")"); err != nil {
+				return Option{}, err
+			}
+			name.WriteString("(" + n + ")")
+		} else {
+			n, err := p.ident()
+			if err != nil {
+				return Option{}, err
+			}
+			name.WriteString(n)
+		}
+		if !p.accept(".") {
+			break
+		}
+		name.WriteString(".")
+	}
+	if err := p.expect("="); err != nil {
+		return Option{}, err
+	}
+	value, err := p.constant()
+	if err != nil {
+		return Option{}, err
+	}
+	return Option{Name: name.String(), Value: value}, nil
+}
+
+// constant parses an option value: an identifier, a number, a string or an
+// aggregate in braces, which is returned as written.
+func (p *parser) constant() (string, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokString:
+		return p.str()
+	case t.kind == tokIdent:
+		return p.fullIdent()
+	case t.kind == tokNumber:
+		return p.next().text, nil
+	case p.is("-") || p.is("+"):
+		sign := p.next().text
+		n := p.next()
+		if n.kind != tokNumber && n.kind != tokIdent {
+			return "", p.errorf(n, "expected number, found %s", n)
+		}
+		return sign + n.text, nil
+	case p.is("{"):
+		var parts []string
+		depth := 0
+		for {
+			t := p.next()
+			switch {
+			case t.kind == tokEOF:
+				return "", p.errorf(t, "unexpected %s", t)
+			case t.kind == tokString:
+				parts = append(parts, strconv.Quote(t.text))
+			default:
+				parts = append(parts, t.text)
+			}
+			if t.kind == tokSymbol && t.text == "{" {
+				depth++
+			} else if t.kind == tokSymbol && t.text == "}" {
+				depth--
+				if depth == 0 {
+					return strings.Join(parts, " "), nil
+				}
+			}
+		}
+	}
+	return "", p.errorf(t, "expected constant, found %s", t)
+}
+
+// options parses `[name = value, ...]`, if present.
+func (p *parser) options() ([]Option, error) {
+	if !p.accept("[") {
+		return nil, nil
+	}
+	var result []Option
+	for {
+		o, err := p.option()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, o)
+		if !p.accept(",") {
+			break
+		}
+	}
+	return result, p.expect("]")
+}
+
+// skipStatement skips a statement up to its ';', or a block in braces.
+func (p *parser) skipStatement() error {
+	depth := 0
+	for {
+		t := p.next()
+		switch {
+		case t.kind == tokEOF:
+			return p.errorf(t, "unexpected %s", t)
+		case t.kind != tokSymbol:
+		case t.text == ";" && depth == 0:
+			return nil
+		case t.text == "{":
+			depth++
+		case t.text == "}":
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+func (p *parser) message() (*Message, error) {
+	start := p.next()
+	m := &Message{CommentLines: p.commentLines(start), Position: start.pos}
+	var err error
+	if m.Name, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.accept("}") {
+		t := p.peek()
+		switch {
+		case t.kind == tokEOF:
+			return nil, p.errorf(t, "unexpected %s", t)
+		case p.accept(";"):
+		case p.is("option"):
+			o, err := p.optionStatement()
+			if err != nil {
+				return nil, err
+			}
+			m.Options = append(m.Options, o)
+		case p.is("message"):
+			nested, err := p.message()
+			if err != nil {
+				return nil, err
+			}
+			m.Messages = append(m.Messages, nested)
+		case p.is("enum"):
+			e, err := p.enum()
+			if err != nil {
+				return nil, err
+			}
+			m.Enums = append(m.Enums, e)
+		case p.is("reserved") || p.is("extensions") || p.is("extend"):
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+		case p.is("oneof"):
+			p.next()
+			name, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("{"); err != nil {
+				return nil, err
+			}
+			for !p.accept("}") {
+				switch {
+				case p.accept(";"):
+				case p.is("option"):
+					if _, err := p.optionStatement(); err != nil {
+						return nil, err
+					}
+				default:
+					f, err := p.field()
+					if err != nil {
+						return nil, err
+					}
+					f.OneOf = name
+					m.Fields = append(m.Fields, f)
+				}
+			}
+		default:
+			f, err := p.field()
+			if err != nil {
+				return nil, err
+			}
+			m.Fields = append(m.Fields, f)
+		}
+	}
+	return m, nil
+}
+
+func (p *parser) field() (*Field, error) {
+	start := p.peek()
+	f := &Field{CommentLines: p.commentLines(start), Position: start.pos}
+	if p.is("repeated") || p.is("optional") || p.is("required") {
+		f.Label = p.next().text
+	}
+	var err error
+	switch {
+	case p.is("group"):
+		return nil, p.errorf(p.peek(), "groups are not supported")
+	case p.is("map") && p.tokens[p.i+1].text == "<":
+		p.next()
+		p.next()
+		if f.KeyType, err = p.ident(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		if f.Type, err = p.fullIdent(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(">"); err != nil {
+			return nil, err
+		}
+	default:
+		if f.Type, err = p.fullIdent(); err != nil {
+			return nil, err
+		}
+	}
+	if f.Name, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("="); err != nil {
+		return nil, err
+	}
+	if f.Number, err = p.integer(); err != nil {
+		return nil, err
+	}
+	if f.Options, err = p.options(); err != nil {
+		return nil, err
+	}
+	if err := p.expect(";"); err != nil {
+		return nil, err
+	}
+	f.TrailingCommentLines = p.trailingCommentLines()
+	return f, nil
+}
+
+func (p *parser) enum() (*Enum, error) {
+	start := p.next()
+	e := &Enum{CommentLines: p.commentLines(start), Position: start.pos}
+	var err error
+	if e.Name, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.accept("}") {
+		t := p.peek()
+		switch {
+		case t.kind == tokEOF:
+			return nil, p.errorf(t, "unexpected %s", t)
+		case p.accept(";"):
+		case p.is("option"):
+			o, err := p.optionStatement()
+			if err != nil {
+				return nil, err
+			}
+			e.Options = append(e.Options, o)
+		case p.is("reserved"):
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+		default:
+			v := &EnumValue{CommentLines: p.commentLines(t), Position: t.pos}
+			if v.Name, err = p.ident(); err != nil {
+				return nil, err
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			if v.Number, err = p.integer(); err != nil {
+				return nil, err
+			}
+			if v.Options, err = p.options(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+			v.TrailingCommentLines = p.trailingCommentLines()
+			e.Values = append(e.Values, v)
+		}
+	}
+	return e, nil
+}
+
+func (p *parser) service() (*Service, error) {
+	start := p.next()
+	s := &Service{CommentLines: p.commentLines(start), Position: start.pos}
+	var err error
+	if s.Name, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.accept("}") {
+		t := p.peek()
+		switch {
+		case t.kind == tokEOF:
+			return nil, p.errorf(t, "unexpected %s", t)
+		case p.accept(";"):
+		case p.is("option"):
+			o, err := p.optionStatement()
+			if err != nil {
+				return nil, err
+			}
+			s.Options = append(s.Options, o)
+		case p.is("rpc"):
+			r, err := p.rpc()
+			if err != nil {
+				return nil, err
+			}
+			s.RPCs = append(s.RPCs, r)
+		default:
+			return nil, p.errorf(t, "unexpected %s", t)
+		}
+	}
+	return s, nil
+}
+
+func (p *parser) rpc() (*RPC, error) {
+	start := p.next()
+	r := &RPC{CommentLines: p.commentLines(start), Position: start.pos}
+	var err error
+	if r.Name, err = p.ident(); err != nil {
+		return nil, err
+	}
+	messageType := func(stream *bool) (string, error) {
+		if err := p.expect("("); err != nil {
+			return "", err
+		}
+		// "stream" may also be the name of the message type.
+		if p.is("stream") && p.tokens[p.i+1].text != ")" {
+			p.next()
+			*stream = true
+		}
+		name, err := p.fullIdent()
+		if err != nil {
+			return "", err
+		}
+		return name, p.expect(")")
+	}
+	if r.Request, err = messageType(&r.ClientStreaming); err != nil {
+		return nil, err
+	}
+	if err := p.expect("returns"); err != nil {
+		return nil, err
+	}
+	if r.Response, err = messageType(&r.ServerStreaming); err != nil {
+		return nil, err
+	}
+	if p.accept("{") {
+		for !p.accept("}") {
+			t := p.peek()
+			switch {
+			case t.kind == tokEOF:
+				return nil, p.errorf(t, "unexpected %s", t)
+			case p.accept(";"):
+			case p.is("option"):
+				o, err := p.optionStatement()
+				if err != nil {
+					return nil, err
+				}
+				r.Options = append(r.Options, o)
+			default:
+				return nil, p.errorf(t, "unexpected %s", t)
+			}
+		}
+	} else if err := p.expect(";"); err != nil {
+		return nil, err
+	}
+	r.TrailingCommentLines = p.trailingCommentLines()
+	return r, nil
+}
@@ -0,0 +1,117 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testProto = `// Package doc.
+syntax = "proto3";
+
+package example.v1;
+
+import public "other.proto";
+option go_package = "github.com/x/example/v1";
+option (custom.opt).name = -1.5e-3;
+
+// Outer is outer.
+message Outer {
+  // Inner is inner.
+  message Inner { int64 id = 0x1; }
+  enum Kind {
+    option allow_alias = true;
+    KIND_UNSPECIFIED = 0;
+    KIND_NEGATIVE = -1; // below zero
+  }
+
+  repeated Inner inners = 1 [(validate.rules).repeated = {min_items: 1, items: {message: {required: true}}}];
+  map<string, .example.v1.Outer.Kind> kinds = 2;
+  oneof value {
+    string text = 3; /* text */
+    bytes data = 4;
+  }
+  reserved 5 to 10, "old";
+  extensions 100 to max;
+}
+
+extend google.protobuf.FieldOptions { string tag = 5000; }
+
+service Svc {
+  rpc Stream(stream Outer) returns (stream Outer.Inner) {
+    option idempotency_level = NO_SIDE_EFFECTS;
+  }
+  rpc Unary(Outer) returns (Outer); // unary
+}
+`
+
+func TestParseFile(t *testing.T) {
+	f, err := ParseFile("example.proto", []byte(testProto))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "proto3", f.Syntax)
+	assert.Equal(t, "example.v1", f.Package)
+	assert.Equal(t, []string{"Package doc."}, f.DocComments)
+	assert.Equal(t, []Import{{Path: "other.proto", Modifier: "public"}}, f.Imports)
+	assert.Equal(t, []Option{{"go_package", "github.com/x/example/v1"}, {"(custom.opt).name", "-1.5e-3"}}, f.Options)
+	path, name := f.GoPackage()
+	assert.Equal(t, "github.com/x/example/v1", path)
+	assert.Equal(t, "v1", name)
+
+	if !assert.Len(t, f.Messages, 1) {
+		return
+	}
+	outer := f.Messages[0]
+	assert.Equal(t, "Outer", outer.Name)
+	assert.Equal(t, []string{"Outer is outer."}, outer.CommentLines)
+	assert.Equal(t, 11, outer.Position.Line)
+	assert.Equal(t, "Inner", outer.Messages[0].Name)
+	assert.Equal(t, []string{"Inner is inner."}, outer.Messages[0].CommentLines)
+	assert.Equal(t, 1, outer.Messages[0].Fields[0].Number)
+
+	kind := outer.Enums[0]
+	assert.Equal(t, []Option{{"allow_alias", "true"}}, kind.Options)
+	assert.Equal(t, -1, kind.Values[1].Number)
+	assert.Equal(t, []string{"below zero"}, kind.Values[1].TrailingCommentLines)
+
+	if assert.Len(t, outer.Fields, 4) {
+		inners, kinds, text, data := outer.Fields[0], outer.Fields[1], outer.Fields[2], outer.Fields[3]
+		assert.Equal(t, "repeated", inners.Label)
+		assert.Equal(t, "Inner", inners.Type)
+		assert.Equal(t, "(validate.rules).repeated", inners.Options[0].Name)
+		assert.Equal(t, "{ min_items : 1 , items : { message : { required : true } } }", inners.Options[0].Value)
+		assert.True(t, kinds.IsMap())
+		assert.Equal(t, "string", kinds.KeyType)
+		assert.Equal(t, ".example.v1.Outer.Kind", kinds.Type)
+		assert.Equal(t, "value", text.OneOf)
+		assert.Equal(t, []string{"text"}, text.TrailingCommentLines)
+		assert.Equal(t, "data", data.Name)
+	}
+
+	if assert.Len(t, f.Services, 1) && assert.Len(t, f.Services[0].RPCs, 2) {
+		stream, unary := f.Services[0].RPCs[0], f.Services[0].RPCs[1]
+		assert.True(t, stream.ClientStreaming)
+		assert.True(t, stream.ServerStreaming)
+		assert.Equal(t, "Outer.Inner", stream.Response)
+		assert.Equal(t, []Option{{"idempotency_level", "NO_SIDE_EFFECTS"}}, stream.Options)
+		assert.False(t, unary.ClientStreaming)
+		assert.Equal(t, []string{"unary"}, unary.TrailingCommentLines)
+	}
+}
+
+func TestParseFileErrors(t *testing.T) {
+	for src, want := range map[string]string{
+		`syntax = "proto4";`:                            `a.proto:1:1: unsupported syntax "proto4"`,
+		"message Foo {\n  string name = ;\n}":           `a.proto:2:17: expected integer, found ";"`,
+		"message Foo {\n  group Bar = 1 {}\n}":          `a.proto:2:3: groups are not supported`,
+		"message Foo {":                                 `a.proto:1:14: unexpected end of file`,
+		`option go_package = "x`:                        `a.proto:1:21: string not terminated`,
+		"/* open":                                       `a.proto:1:1: comment not terminated`,
+		"service Foo { rpc Bar(A) returns (B) string }": `a.proto:1:38: expected ";", found "string"`,
+		"foo bar;":                                      `a.proto:1:1: unexpected "foo"`,
+	} {
+		_, err := ParseFile("a.proto", []byte(src))
+		assert.EqualError(t, err, want, src)
+	}
+}
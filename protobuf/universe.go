@@ -0,0 +1,349 @@
+package protobuf
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zhaolion/gen/types"
+)
+
+// AddFiles adds the messages, enums and services of the given files to u, as
+// types of Kind Protobuf:
+//   - a message is struct-like: its fields are Members, tagged with
+//     `protobuf:"<number>"` (and `protobuf_oneof:"<name>"` for oneof fields).
+//     Repeated fields are slices, map fields maps.
+//   - an enum has Underlying types.Int32, and its values are Members of that
+//     type, tagged with their number.
+//   - a service is interface-like: its rpcs are Methods, taking the request
+//     and returning the response message. Streams are chans of messages.
+//
+// Types are put in the Package named after the proto package, with nested
+// types named like "Outer.Inner", so that Name.String() is the full proto
+// name. Name.Path is the Go import path of the go_package option, see GoType.
+// References to types which are not in the given files are left as markers
+// of Kind Unknown, which Universe.Validate reports.
+func AddFiles(u types.Universe, files ...*File) error {
+	c := &converter{u: u, declared: map[string]*types.Type{}, packages: map[string]bool{}}
+	for _, f := range files {
+		if f.Package == "" {
+			return fmt.Errorf("%s: missing package statement", f.Path)
+		}
+		for name := f.Package; name != ""; name = parentScope(name) {
+			c.packages[name] = true
+		}
+	}
+	for _, f := range files {
+		c.declare(f)
+	}
+	for _, f := range files {
+		c.define(f)
+	}
+	return nil
+}
+
+type converter struct {
+	u types.Universe
+	// Messages and enums by their full name, without leading dot.
+	declared map[string]*types.Type
+	// Packages of the files, and their parent packages.
+	packages map[string]bool
+}
+
+// scalars maps proto scalar types to Go types.
+var scalars = map[string]*types.Type{
+	"double":   types.Float64,
+	"float":    types.Float32,
+	"int32":    types.Int32,
+	"int64":    types.Int64,
+	"uint32":   types.Uint32,
+	"uint64":   types.Uint64,
+	"sint32":   types.Int32,
+	"sint64":   types.Int64,
+	"fixed32":  types.Uint32,
+	"fixed64":  types.Uint64,
+	"sfixed32": types.Int32,
+	"sfixed64": types.Int64,
+	"bool":     types.Bool,
+	"string":   types.String,
+}
+
+func (c *converter) declare(f *File) {
+	goPath, _ := f.GoPackage()
+	p := c.u.Package(f.Package)
+	p.Name = f.Package
+	p.SourcePath = filepath.Dir(f.Path)
+	file := p.File(f.Path)
+	file.DocComments = f.DocComments
+
+	newType := func(name string, comments []string, pos types.Position) *types.Type {
+		t := c.u.Type(types.Name{Package: f.Package, Name: name})
+		t.Name.Path = goPath
+		t.Kind = types.Protobuf
+		t.CommentLines = comments
+		t.Position = pos
+		c.declared[f.Package+"."+name] = t
+		return t
+	}
+	var declareMessage func(prefix string, m *Message) *types.Type
+	declareEnum := func(prefix string, e *Enum) *types.Type {
+		return newType(prefix+e.Name, e.CommentLines, e.Position)
+	}
+	declareMessage = func(prefix string, m *Message) *types.Type {
+		t := newType(prefix+m.Name, m.CommentLines, m.Position)
+		for _, nested := range m.Messages {
+			declareMessage(t.Name.Name+".", nested)
+		}
+		for _, e := range m.Enums {
+			declareEnum(t.Name.Name+".", e)
+		}
+		return t
+	}
+
+	var objects []*types.Type
+	for _, m := range f.Messages {
+		objects = append(objects, declareMessage("", m))
+	}
+	for _, e := range f.Enums {
+		objects = append(objects, declareEnum("", e))
+	}
+	for _, s := range f.Services {
+		t := newType(s.Name, s.CommentLines, s.Position)
+		t.Methods = map[string]*types.Type{}
+		objects = append(objects, t)
+	}
+	file.Objects = types.SortBySource(objects)
+}
+
+func (c *converter) define(f *File) {
+	var defineMessage func(scope string, m *Message)
+	defineMessage = func(scope string, m *Message) {
+		t := c.declared[scope+"."+m.Name]
+		scope = t.Name.String()
+		for _, field := range m.Fields {
+			ft := c.resolve(f, scope, field.Type)
+			switch {
+			case field.IsMap():
+				ft = c.mapOf(c.resolve(f, scope, field.KeyType), ft)
+			case field.Label == "repeated":
+				ft = c.sliceOf(ft)
+			}
+			tags := fmt.Sprintf(`protobuf:"%d"`, field.Number)
+			if field.OneOf != "" {
+				tags += fmt.Sprintf(` protobuf_oneof:"%s"`, field.OneOf)
+			}
+			t.Members = append(t.Members, types.Member{
+				Name:                 field.Name,
+				Type:                 ft,
+				Tags:                 tags,
+				CommentLines:         field.CommentLines,
+				TrailingCommentLines: field.TrailingCommentLines,
+				Position:             field.Position,
+			})
+		}
+		for _, nested := range m.Messages {
+			defineMessage(scope, nested)
+		}
+		for _, e := range m.Enums {
+			c.defineEnum(scope, e)
+		}
+	}
+	for _, m := range f.Messages {
+		defineMessage(f.Package, m)
+	}
+	for _, e := range f.Enums {
+		c.defineEnum(f.Package, e)
+	}
+
+	for _, s := range f.Services {
+		t := c.u.Package(f.Package).Type(s.Name)
+		for _, rpc := range s.RPCs {
+			req := c.resolve(f, f.Package, rpc.Request)
+			if rpc.ClientStreaming {
+				req = c.chanOf(req)
+			}
+			res := c.resolve(f, f.Package, rpc.Response)
+			if rpc.ServerStreaming {
+				res = c.chanOf(res)
+			}
+			// The rpcs are only reachable as methods of the service, they
+			// are not types of the package.
+			t.Methods[rpc.Name] = &types.Type{
+				Name:                 types.Name{Package: f.Package, Name: s.Name + "." + rpc.Name, Path: t.Name.Path},
+				Kind:                 types.Func,
+				CommentLines:         rpc.CommentLines,
+				TrailingCommentLines: rpc.TrailingCommentLines,
+				Position:             rpc.Position,
+				Signature: &types.Signature{
+					Receiver:     t,
+					MethodName:   rpc.Name,
+					Parameters:   []*types.Type{req},
+					Results:      []*types.Type{res},
+					CommentLines: rpc.CommentLines,
+				},
+			}
+		}
+	}
+
+	p := c.u.Package(f.Package)
+	for _, i := range f.Imports {
+		for _, other := range c.u {
+			for path, file := range other.Files {
+				if other.Path != p.Path && strings.HasSuffix(filepath.ToSlash(path), "/"+i.Path) {
+					p.Imports[other.Path] = file.Package
+				}
+			}
+		}
+	}
+}
+
+func (c *converter) defineEnum(scope string, e *Enum) {
+	t := c.declared[scope+"."+e.Name]
+	t.Underlying = types.Int32
+	for _, v := range e.Values {
+		t.Members = append(t.Members, types.Member{
+			Name:                 v.Name,
+			Type:                 types.Int32,
+			Tags:                 fmt.Sprintf(`protobuf:"%d"`, v.Number),
+			CommentLines:         v.CommentLines,
+			TrailingCommentLines: v.TrailingCommentLines,
+			Position:             v.Position,
+		})
+	}
+}
+
+// resolve looks up a type reference following the proto scoping rules: a
+// name with a leading dot is fully qualified, otherwise its first component
+// is searched in scope and its parents, and the rest of the name is resolved
+// from where the first component was found, like protoc does.
+func (c *converter) resolve(f *File, scope, name string) *types.Type {
+	if t, ok := scalars[name]; ok {
+		return t
+	}
+	if name == "bytes" {
+		return c.sliceOf(types.Byte)
+	}
+	if strings.HasPrefix(name, ".") {
+		if t, ok := c.declared[name[1:]]; ok {
+			return t
+		}
+		return c.marker(name[1:])
+	}
+	first, rest := name, ""
+	if i := strings.Index(name, "."); i >= 0 {
+		first, rest = name[:i], name[i:]
+	}
+	for s := scope; ; s = parentScope(s) {
+		candidate := first
+		if s != "" {
+			candidate = s + "." + first
+		}
+		_, found := c.declared[candidate]
+		if found || (rest != "" && c.packages[candidate]) {
+			if t, ok := c.declared[candidate+rest]; ok {
+				return t
+			}
+			return c.marker(candidate + rest)
+		}
+		if s == "" {
+			break
+		}
+	}
+	if rest != "" {
+		return c.marker(name)
+	}
+	return c.marker(f.Package + "." + name)
+}
+
+// parentScope returns the scope enclosing the dotted name scope, "" for a
+// top level name.
+func parentScope(scope string) string {
+	i := strings.LastIndex(scope, ".")
+	if i < 0 {
+		return ""
+	}
+	return scope[:i]
+}
+
+// marker returns a placeholder for an unknown type, with the last element of
+// the full name as type name. A name without a package, like ".Other", is
+// placed in the package "".
+func (c *converter) marker(fullName string) *types.Type {
+	i := strings.LastIndex(fullName, ".")
+	if i < 0 {
+		return c.u.Type(types.Name{Name: fullName})
+	}
+	return c.u.Type(types.Name{Package: fullName[:i], Name: fullName[i+1:]})
+}
+
+func (c *converter) sliceOf(elem *types.Type) *types.Type {
+	t := c.u.Type(types.Name{Name: "[]" + elem.String()})
+	t.Kind, t.Elem = types.Slice, elem
+	return t
+}
+
+func (c *converter) mapOf(key, elem *types.Type) *types.Type {
+	t := c.u.Type(types.Name{Name: "map[" + key.String() + "]" + elem.String()})
+	t.Kind, t.Key, t.Elem = types.Map, key, elem
+	return t
+}
+
+func (c *converter) chanOf(elem *types.Type) *types.Type {
+	t := c.u.Type(types.Name{Name: "chan " + elem.String()})
+	t.Kind, t.Elem = types.Chan, elem
+	return t
+}
+
+// IsMessage returns true if t is a protobuf message.
+func IsMessage(t *types.Type) bool {
+	return t.Kind == types.Protobuf && t.Underlying == nil && t.Methods == nil
+}
+
+// IsEnum returns true if t is a protobuf enum.
+func IsEnum(t *types.Type) bool {
+	return t.Kind == types.Protobuf && t.Underlying != nil
+}
+
+// IsService returns true if t is a protobuf service.
+func IsService(t *types.Type) bool {
+	return t.Kind == types.Protobuf && t.Methods != nil
+}
+
+// GoName returns the name of the Go type generated for the protobuf type t
+// by protoc-gen-go: nested names are joined with underscores, and services
+// map to their server interface, e.g. "Outer_Inner" or "GreeterServer".
+func GoName(t *types.Type) string {
+	name := strings.Replace(t.Name.Name, ".", "_", -1)
+	if IsService(t) {
+		name += "Server"
+	}
+	return name
+}
+
+// GoType returns the Go type generated for the protobuf type t, if its
+// go_package has been loaded into u.
+func GoType(u types.Universe, t *types.Type) (*types.Type, bool) {
+	if t.Kind != types.Protobuf || t.Name.Path == "" {
+		return nil, false
+	}
+	return u.LookupType(types.Name{Package: t.Name.Path, Name: GoName(t)})
+}
+
+// ProtoType returns the protobuf type the Go type goType was generated for,
+// if it is in u.
+func ProtoType(u types.Universe, goType *types.Type) (*types.Type, bool) {
+	paths := make([]string, 0, len(u))
+	for path := range u {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for _, t := range u[path].SortedTypes() {
+			if t.Kind == types.Protobuf && t.Name.Path == goType.Name.Package && GoName(t) == goType.Name.Name {
+				return t, true
+			}
+		}
+	}
+	return nil, false
+}
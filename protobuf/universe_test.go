@@ -0,0 +1,102 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaolion/gen/types"
+)
+
+func TestAddFiles(t *testing.T) {
+	f, err := ParseFile("/src/example.proto", []byte(testProto))
+	if !assert.NoError(t, err) {
+		return
+	}
+	u := types.Universe{}
+	assert.NoError(t, AddFiles(u, f))
+
+	p := u.Package("example.v1")
+	var names []string
+	for _, typ := range p.SortedTypes() {
+		names = append(names, typ.String())
+	}
+	assert.Equal(t, []string{"example.v1.Outer", "example.v1.Outer.Inner", "example.v1.Outer.Kind", "example.v1.Svc"}, names)
+	assert.Len(t, p.File("/src/example.proto").Objects, 2)
+
+	outer, inner, kind, svc := p.Type("Outer"), p.Type("Outer.Inner"), p.Type("Outer.Kind"), p.Type("Svc")
+	assert.True(t, IsMessage(outer))
+	assert.True(t, IsEnum(kind))
+	assert.True(t, IsService(svc))
+	assert.False(t, IsMessage(svc))
+	assert.Equal(t, "github.com/x/example/v1", outer.Name.Path)
+	assert.Equal(t, "Outer_Inner", GoName(inner))
+	assert.Equal(t, "SvcServer", GoName(svc))
+
+	if assert.Len(t, outer.Members, 4) {
+		assert.Equal(t, "[]example.v1.Outer.Inner", outer.Members[0].Type.String())
+		assert.Equal(t, inner, outer.Members[0].Type.Elem)
+		assert.Equal(t, `protobuf:"1"`, outer.Members[0].Tags)
+		assert.Equal(t, kind, outer.Members[1].Type.Elem)
+		assert.Equal(t, types.String, outer.Members[1].Type.Key)
+		assert.Equal(t, `protobuf:"3" protobuf_oneof:"value"`, outer.Members[2].Tags)
+		assert.Equal(t, "[]byte", outer.Members[3].Type.String())
+	}
+	assert.Equal(t, types.Int64, inner.Members[0].Type)
+	assert.Equal(t, types.Int32, kind.Underlying)
+	assert.Equal(t, `protobuf:"-1"`, kind.Members[1].Tags)
+
+	stream := svc.Methods["Stream"]
+	assert.Equal(t, svc, stream.Signature.Receiver)
+	assert.Equal(t, "chan example.v1.Outer", stream.Signature.Parameters[0].String())
+	assert.Equal(t, inner, stream.Signature.Results[0].Elem)
+	assert.Equal(t, outer, svc.Methods["Unary"].Signature.Parameters[0])
+
+	// Protobuf types are linked to the Go types generated for them.
+	goPkg := u.Package("github.com/x/example/v1")
+	goInner := goPkg.Type("Outer_Inner")
+	goInner.Kind = types.Struct
+	got, ok := GoType(u, inner)
+	assert.True(t, ok)
+	assert.Equal(t, goInner, got)
+	_, ok = GoType(u, outer)
+	assert.False(t, ok)
+	got, ok = ProtoType(u, goInner)
+	assert.True(t, ok)
+	assert.Equal(t, inner, got)
+
+	// Unresolved references are left for Validate to report.
+	f, err = ParseFile("/src/ref.proto", []byte(`syntax = "proto3"; package ref; message Ref { google.protobuf.Timestamp at = 1; Missing m = 2; }`))
+	assert.NoError(t, err)
+	assert.NoError(t, AddFiles(u, f))
+	ref := u.Package("ref").Type("Ref")
+	assert.Equal(t, "google.protobuf.Timestamp", ref.Members[0].Type.String())
+	assert.Equal(t, "ref.Missing", ref.Members[1].Type.String())
+	assert.Error(t, u.Validate("ref"))
+
+	f, err = ParseFile("/src/a.proto", []byte(`syntax = "proto3"; package a; import "other.proto"; message M { .Other x = 1; }`))
+	assert.NoError(t, err)
+	u2 := types.Universe{}
+	assert.NoError(t, AddFiles(u2, f))
+	other := u2.Package("a").Type("M").Members[0].Type
+	assert.Equal(t, types.Name{Name: "Other"}, other.Name)
+	assert.Equal(t, types.Unknown, other.Kind)
+	assert.EqualError(t, u2.Validate("a"), "Other: dangling type marker: never defined")
+
+	// Dotted references resolve from their first component outward: A.C in
+	// B finds B.A first, which has no C.
+	f, err = ParseFile("/src/dotted.proto", []byte(`syntax = "proto3"; package p.q;
+message A { message C {} }
+message B { message A {} A.C c = 1; }
+message D { A.C c = 1; q.A a = 2; }`))
+	assert.NoError(t, err)
+	u3 := types.Universe{}
+	assert.NoError(t, AddFiles(u3, f))
+	pq := u3.Package("p.q")
+	assert.Equal(t, "p.q.B.A.C", pq.Type("B").Members[0].Type.String())
+	assert.Equal(t, types.Unknown, pq.Type("B").Members[0].Type.Kind)
+	assert.Equal(t, pq.Type("A.C"), pq.Type("D").Members[0].Type)
+	assert.Equal(t, pq.Type("A"), pq.Type("D").Members[1].Type)
+
+	f, _ = ParseFile("/src/nopkg.proto", []byte(`syntax = "proto3";`))
+	assert.EqualError(t, AddFiles(u, f), "/src/nopkg.proto: missing package statement")
+}
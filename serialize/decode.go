@@ -0,0 +1,195 @@
+package serialize
+
+import (
+	"fmt"
+
+	"github.com/zhaolion/gen/types"
+)
+
+// Decode converts a Document back to a linked types.Universe.
+func Decode(d *Document) (types.Universe, error) {
+	if d.Version != SchemaVersion {
+		return nil, fmt.Errorf("unsupported schema version %q, want %q", d.Version, SchemaVersion)
+	}
+
+	// Types by declaration and Ref. Builtins are shared with package types.
+	index := map[string]map[Ref]*types.Type{
+		typeDeclaration:     {},
+		functionDeclaration: {},
+		variableDeclaration: {},
+		constantDeclaration: {},
+	}
+	builtins := types.Universe{}
+	shared := map[*types.Type]bool{}
+	for i := range d.Types {
+		in := &d.Types[i]
+		byRef, ok := index[in.Declaration]
+		if !ok {
+			return nil, fmt.Errorf("type %q: unknown declaration %q", in.Ref, in.Declaration)
+		}
+		if _, ok := byRef[in.Ref]; ok {
+			return nil, fmt.Errorf("type %q: duplicate %s", in.Ref, in.Declaration)
+		}
+		name := types.Name{Package: in.Package, Name: in.Name, Path: in.Path}
+		if in.Declaration == typeDeclaration && in.Package == "" {
+			if t, ok := builtins.LookupType(name); ok && t.Kind == in.Kind {
+				byRef[in.Ref] = t
+				shared[t] = true
+				continue
+			}
+		}
+		byRef[in.Ref] = &types.Type{Name: name}
+	}
+
+	lookup := func(from string, ref Ref) (*types.Type, error) {
+		if ref == "" {
+			return nil, nil
+		}
+		if t, ok := index[typeDeclaration][ref]; ok {
+			return t, nil
+		}
+		return nil, fmt.Errorf("%s references unknown type %q", from, ref)
+	}
+
+	for i := range d.Types {
+		in := &d.Types[i]
+		out := index[in.Declaration][in.Ref]
+		if shared[out] {
+			continue
+		}
+		if err := decodeType(in, out, lookup); err != nil {
+			return nil, err
+		}
+	}
+
+	u := types.Universe{}
+	for _, in := range d.Packages {
+		p := u.Package(in.Path)
+		p.SourcePath = in.SourcePath
+		p.Name = in.Name
+		p.DocComments = in.DocComments
+		p.Comments = in.Comments
+		for _, m := range []struct {
+			declaration string
+			in          map[string]Ref
+			out         map[string]*types.Type
+		}{
+			{typeDeclaration, in.Types, p.Types},
+			{functionDeclaration, in.Functions, p.Functions},
+			{variableDeclaration, in.Variables, p.Variables},
+			{constantDeclaration, in.Constants, p.Constants},
+		} {
+			for name, ref := range m.in {
+				t, ok := index[m.declaration][ref]
+				if !ok {
+					return nil, fmt.Errorf("package %q references unknown %s %q", in.Path, m.declaration, ref)
+				}
+				m.out[name] = t
+			}
+		}
+		for _, path := range in.Imports {
+			p.Imports[path] = u.Package(path)
+		}
+		for _, f := range in.Files {
+			file := p.File(f.Path)
+			file.BuildConstraints = f.BuildConstraints
+			file.DocComments = f.DocComments
+			for _, i := range f.Imports {
+				file.Imports = append(file.Imports, &types.Import{Path: i.Path, Name: i.Name, Package: u.Package(i.Path)})
+			}
+			for _, ref := range f.Objects {
+				obj := lookupObject(index, ref)
+				if obj == nil {
+					return nil, fmt.Errorf("file %q references unknown object %q", f.Path, ref)
+				}
+				file.Objects = append(file.Objects, obj)
+			}
+		}
+	}
+	return u, nil
+}
+
+func decodeType(in *Type, out *types.Type, lookup func(from string, ref Ref) (*types.Type, error)) error {
+	var err error
+	resolve := func(ref Ref) *types.Type {
+		t, e := lookup(fmt.Sprintf("type %q", in.Ref), ref)
+		if e != nil && err == nil {
+			err = e
+		}
+		return t
+	}
+
+	out.Kind = in.Kind
+	out.CommentLines = in.CommentLines
+	out.SecondClosestCommentLines = in.SecondClosestCommentLines
+	out.TrailingCommentLines = in.TrailingCommentLines
+	out.GroupCommentLines = in.GroupCommentLines
+	out.Elem = resolve(in.Elem)
+	out.Key = resolve(in.Key)
+	out.Underlying = resolve(in.Underlying)
+	out.Position = decodePosition(in.Position)
+	for _, m := range in.Members {
+		out.Members = append(out.Members, types.Member{
+			Name:                 m.Name,
+			Embedded:             m.Embedded,
+			CommentLines:         m.CommentLines,
+			TrailingCommentLines: m.TrailingCommentLines,
+			Tags:                 m.Tags,
+			Type:                 resolve(m.Type),
+			Position:             decodePosition(m.Position),
+		})
+	}
+	if len(in.Methods) > 0 {
+		out.Methods = map[string]*types.Type{}
+		for name, ref := range in.Methods {
+			out.Methods[name] = resolve(ref)
+		}
+	}
+	for _, name := range in.ExplicitMethods {
+		m, ok := out.Methods[name]
+		if !ok {
+			return fmt.Errorf("type %q: explicit method %q is not a method", in.Ref, name)
+		}
+		if out.ExplicitMethods == nil {
+			out.ExplicitMethods = map[string]*types.Type{}
+		}
+		out.ExplicitMethods[name] = m
+	}
+	for _, ref := range in.EmbeddedInterfaces {
+		out.EmbeddedInterfaces = append(out.EmbeddedInterfaces, resolve(ref))
+	}
+	if s := in.Signature; s != nil {
+		out.Signature = &types.Signature{
+			Receiver:        resolve(s.Receiver),
+			Variadic:        s.Variadic,
+			MethodName:      s.MethodName,
+			PointerReceiver: s.PointerReceiver,
+			CommentLines:    s.CommentLines,
+		}
+		for _, ref := range s.Parameters {
+			out.Signature.Parameters = append(out.Signature.Parameters, resolve(ref))
+		}
+		for _, ref := range s.Results {
+			out.Signature.Results = append(out.Signature.Results, resolve(ref))
+		}
+	}
+	return err
+}
+
+// lookupObject finds a package-level object by Ref in any declaration
+// namespace.
+func lookupObject(index map[string]map[Ref]*types.Type, ref Ref) *types.Type {
+	for _, declaration := range []string{typeDeclaration, functionDeclaration, variableDeclaration, constantDeclaration} {
+		if t, ok := index[declaration][ref]; ok {
+			return t
+		}
+	}
+	return nil
+}
+
+func decodePosition(p *Position) types.Position {
+	if p == nil {
+		return types.Position{}
+	}
+	return types.Position{Filename: p.Filename, Offset: p.Offset, Line: p.Line, Column: p.Column}
+}
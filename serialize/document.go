@@ -0,0 +1,126 @@
+package serialize
+
+import (
+	"github.com/zhaolion/gen/types"
+)
+
+// SchemaVersion identifies the format of a Document. It changes whenever the
+// format changes in a way older readers can't handle.
+const SchemaVersion = "gen.universe/v1"
+
+// Document is the serialized form of a types.Universe. The object graph is
+// flattened: every type is listed once in Types, and everything else refers
+// to types by their fully qualified name (types.Name.String()), see Ref.
+type Document struct {
+	// Always SchemaVersion.
+	Version string `json:"version" yaml:"version"`
+
+	// All packages, sorted by path.
+	Packages []Package `json:"packages" yaml:"packages"`
+
+	// All types, functions, variables and constants, sorted by Ref.
+	Types []Type `json:"types" yaml:"types"`
+}
+
+// Ref is the fully qualified name of a type, as returned by
+// types.Name.String(), e.g. "github.com/x/model.Foo", "string" or
+// "*github.com/x/model.Foo".
+type Ref = string
+
+// Package is the serialized form of a types.Package.
+type Package struct {
+	Path        string   `json:"path" yaml:"path"`
+	SourcePath  string   `json:"sourcePath,omitempty" yaml:"sourcePath,omitempty"`
+	Name        string   `json:"name,omitempty" yaml:"name,omitempty"`
+	DocComments []string `json:"docComments,omitempty" yaml:"docComments,omitempty"`
+	Comments    []string `json:"comments,omitempty" yaml:"comments,omitempty"`
+
+	// Refs indexed by name, as in the maps of types.Package.
+	Types     map[string]Ref `json:"types,omitempty" yaml:"types,omitempty"`
+	Functions map[string]Ref `json:"functions,omitempty" yaml:"functions,omitempty"`
+	Variables map[string]Ref `json:"variables,omitempty" yaml:"variables,omitempty"`
+	Constants map[string]Ref `json:"constants,omitempty" yaml:"constants,omitempty"`
+
+	// Sorted package paths.
+	Imports []string `json:"imports,omitempty" yaml:"imports,omitempty"`
+
+	// Files, sorted by path.
+	Files []File `json:"files,omitempty" yaml:"files,omitempty"`
+}
+
+// File is the serialized form of a types.File.
+type File struct {
+	Path             string   `json:"path" yaml:"path"`
+	BuildConstraints []string `json:"buildConstraints,omitempty" yaml:"buildConstraints,omitempty"`
+	DocComments      []string `json:"docComments,omitempty" yaml:"docComments,omitempty"`
+	Imports          []Import `json:"imports,omitempty" yaml:"imports,omitempty"`
+	Objects          []Ref    `json:"objects,omitempty" yaml:"objects,omitempty"`
+}
+
+// Import is the serialized form of a types.Import.
+type Import struct {
+	Path string `json:"path" yaml:"path"`
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+}
+
+// Type is the serialized form of a types.Type.
+type Type struct {
+	Ref Ref `json:"ref" yaml:"ref"`
+
+	Package string     `json:"package,omitempty" yaml:"package,omitempty"`
+	Name    string     `json:"name" yaml:"name"`
+	Path    string     `json:"path,omitempty" yaml:"path,omitempty"`
+	Kind    types.Kind `json:"kind" yaml:"kind"`
+
+	// Which map of the package the entry belongs to: "type", "function",
+	// "variable" or "constant".
+	Declaration string `json:"declaration" yaml:"declaration"`
+
+	CommentLines              []string `json:"commentLines,omitempty" yaml:"commentLines,omitempty"`
+	SecondClosestCommentLines []string `json:"secondClosestCommentLines,omitempty" yaml:"secondClosestCommentLines,omitempty"`
+	TrailingCommentLines      []string `json:"trailingCommentLines,omitempty" yaml:"trailingCommentLines,omitempty"`
+	GroupCommentLines         []string `json:"groupCommentLines,omitempty" yaml:"groupCommentLines,omitempty"`
+
+	Members    []Member `json:"members,omitempty" yaml:"members,omitempty"`
+	Elem       Ref      `json:"elem,omitempty" yaml:"elem,omitempty"`
+	Key        Ref      `json:"key,omitempty" yaml:"key,omitempty"`
+	Underlying Ref      `json:"underlying,omitempty" yaml:"underlying,omitempty"`
+
+	// Method name to Ref of the method's Func type.
+	Methods            map[string]Ref `json:"methods,omitempty" yaml:"methods,omitempty"`
+	ExplicitMethods    []string       `json:"explicitMethods,omitempty" yaml:"explicitMethods,omitempty"`
+	EmbeddedInterfaces []Ref          `json:"embeddedInterfaces,omitempty" yaml:"embeddedInterfaces,omitempty"`
+
+	Signature *Signature `json:"signature,omitempty" yaml:"signature,omitempty"`
+	Position  *Position  `json:"position,omitempty" yaml:"position,omitempty"`
+}
+
+// Member is the serialized form of a types.Member.
+type Member struct {
+	Name                 string    `json:"name" yaml:"name"`
+	Embedded             bool      `json:"embedded,omitempty" yaml:"embedded,omitempty"`
+	CommentLines         []string  `json:"commentLines,omitempty" yaml:"commentLines,omitempty"`
+	TrailingCommentLines []string  `json:"trailingCommentLines,omitempty" yaml:"trailingCommentLines,omitempty"`
+	Tags                 string    `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Type                 Ref       `json:"type" yaml:"type"`
+	Position             *Position `json:"position,omitempty" yaml:"position,omitempty"`
+}
+
+// Signature is the serialized form of a types.Signature.
+type Signature struct {
+	Receiver        Ref      `json:"receiver,omitempty" yaml:"receiver,omitempty"`
+	Parameters      []Ref    `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Results         []Ref    `json:"results,omitempty" yaml:"results,omitempty"`
+	Variadic        bool     `json:"variadic,omitempty" yaml:"variadic,omitempty"`
+	MethodName      string   `json:"methodName,omitempty" yaml:"methodName,omitempty"`
+	PointerReceiver bool     `json:"pointerReceiver,omitempty" yaml:"pointerReceiver,omitempty"`
+	CommentLines    []string `json:"commentLines,omitempty" yaml:"commentLines,omitempty"`
+}
+
+// Position is the serialized form of a types.Position.
+type Position struct {
+	Filename string `json:"filename" yaml:"filename"`
+	Offset   int    `json:"offset" yaml:"offset"`
+	Line     int    `json:"line" yaml:"line"`
+	Column   int    `json:"column" yaml:"column"`
+}
@@ -0,0 +1,178 @@
+package serialize
+
+import (
+	"sort"
+
+	"github.com/zhaolion/gen/types"
+)
+
+// The possible values of Type.Declaration.
+const (
+	typeDeclaration     = "type"
+	functionDeclaration = "function"
+	variableDeclaration = "variable"
+	constantDeclaration = "constant"
+)
+
+// Encode converts u to a Document.
+func Encode(u types.Universe) *Document {
+	d := &Document{Version: SchemaVersion}
+
+	paths := make([]string, 0, len(u))
+	for path := range u {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// The same type may be found under several names, e.g. builtin byte
+	// and uint8. Methods which are not package types, like the rpcs of
+	// protobuf services, are added along with their receiver.
+	seen := map[*types.Type]bool{}
+	var add func(m map[string]*types.Type, declaration string)
+	add = func(m map[string]*types.Type, declaration string) {
+		for _, t := range m {
+			if !seen[t] {
+				seen[t] = true
+				d.Types = append(d.Types, encodeType(t, declaration))
+				add(t.Methods, typeDeclaration)
+			}
+		}
+	}
+	for _, path := range paths {
+		p := u[path]
+		d.Packages = append(d.Packages, encodePackage(p))
+		add(p.Types, typeDeclaration)
+		add(p.Functions, functionDeclaration)
+		add(p.Variables, variableDeclaration)
+		add(p.Constants, constantDeclaration)
+	}
+	sort.Slice(d.Types, func(i, j int) bool {
+		if d.Types[i].Ref != d.Types[j].Ref {
+			return d.Types[i].Ref < d.Types[j].Ref
+		}
+		return d.Types[i].Declaration < d.Types[j].Declaration
+	})
+	return d
+}
+
+func encodePackage(p *types.Package) Package {
+	out := Package{
+		Path:        p.Path,
+		SourcePath:  p.SourcePath,
+		Name:        p.Name,
+		DocComments: p.DocComments,
+		Comments:    p.Comments,
+		Types:       refs(p.Types),
+		Functions:   refs(p.Functions),
+		Variables:   refs(p.Variables),
+		Constants:   refs(p.Constants),
+	}
+	for path := range p.Imports {
+		out.Imports = append(out.Imports, path)
+	}
+	sort.Strings(out.Imports)
+
+	for _, f := range p.Files {
+		file := File{
+			Path:             f.Path,
+			BuildConstraints: f.BuildConstraints,
+			DocComments:      f.DocComments,
+		}
+		for _, i := range f.Imports {
+			file.Imports = append(file.Imports, Import{Path: i.Path, Name: i.Name})
+		}
+		for _, obj := range f.Objects {
+			file.Objects = append(file.Objects, ref(obj))
+		}
+		out.Files = append(out.Files, file)
+	}
+	sort.Slice(out.Files, func(i, j int) bool { return out.Files[i].Path < out.Files[j].Path })
+	return out
+}
+
+func encodeType(t *types.Type, declaration string) Type {
+	out := Type{
+		Ref:         ref(t),
+		Package:     t.Name.Package,
+		Name:        t.Name.Name,
+		Path:        t.Name.Path,
+		Kind:        t.Kind,
+		Declaration: declaration,
+
+		CommentLines:              t.CommentLines,
+		SecondClosestCommentLines: t.SecondClosestCommentLines,
+		TrailingCommentLines:      t.TrailingCommentLines,
+		GroupCommentLines:         t.GroupCommentLines,
+
+		Elem:       ref(t.Elem),
+		Key:        ref(t.Key),
+		Underlying: ref(t.Underlying),
+
+		Position: encodePosition(t.Position),
+	}
+	for _, m := range t.Members {
+		out.Members = append(out.Members, Member{
+			Name:                 m.Name,
+			Embedded:             m.Embedded,
+			CommentLines:         m.CommentLines,
+			TrailingCommentLines: m.TrailingCommentLines,
+			Tags:                 m.Tags,
+			Type:                 ref(m.Type),
+			Position:             encodePosition(m.Position),
+		})
+	}
+	if len(t.Methods) > 0 {
+		out.Methods = map[string]Ref{}
+		for name, m := range t.Methods {
+			out.Methods[name] = ref(m)
+		}
+	}
+	for name := range t.ExplicitMethods {
+		out.ExplicitMethods = append(out.ExplicitMethods, name)
+	}
+	sort.Strings(out.ExplicitMethods)
+	for _, e := range t.EmbeddedInterfaces {
+		out.EmbeddedInterfaces = append(out.EmbeddedInterfaces, ref(e))
+	}
+	if s := t.Signature; s != nil {
+		out.Signature = &Signature{
+			Receiver:        ref(s.Receiver),
+			Variadic:        s.Variadic,
+			MethodName:      s.MethodName,
+			PointerReceiver: s.PointerReceiver,
+			CommentLines:    s.CommentLines,
+		}
+		for _, p := range s.Parameters {
+			out.Signature.Parameters = append(out.Signature.Parameters, ref(p))
+		}
+		for _, r := range s.Results {
+			out.Signature.Results = append(out.Signature.Results, ref(r))
+		}
+	}
+	return out
+}
+
+func encodePosition(p types.Position) *Position {
+	if !p.IsValid() {
+		return nil
+	}
+	return &Position{Filename: p.Filename, Offset: p.Offset, Line: p.Line, Column: p.Column}
+}
+
+func ref(t *types.Type) Ref {
+	if t == nil {
+		return ""
+	}
+	return t.Name.String()
+}
+
+func refs(m map[string]*types.Type) map[string]Ref {
+	if len(m) == 0 {
+		return nil
+	}
+	result := make(map[string]Ref, len(m))
+	for name, t := range m {
+		result[name] = ref(t)
+	}
+	return result
+}
@@ -0,0 +1,37 @@
+package serialize
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/zhaolion/gen/types"
+	"gopkg.in/yaml.v3"
+)
+
+// EncodeJSON serializes u as indented JSON.
+func EncodeJSON(u types.Universe) ([]byte, error) {
+	return json.MarshalIndent(Encode(u), "", "  ")
+}
+
+// DecodeJSON loads a Universe serialized with EncodeJSON.
+func DecodeJSON(data []byte) (types.Universe, error) {
+	d := &Document{}
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, fmt.Errorf("invalid universe document: %v", err)
+	}
+	return Decode(d)
+}
+
+// EncodeYAML serializes u as YAML.
+func EncodeYAML(u types.Universe) ([]byte, error) {
+	return yaml.Marshal(Encode(u))
+}
+
+// DecodeYAML loads a Universe serialized with EncodeYAML.
+func DecodeYAML(data []byte) (types.Universe, error) {
+	d := &Document{}
+	if err := yaml.Unmarshal(data, d); err != nil {
+		return nil, fmt.Errorf("invalid universe document: %v", err)
+	}
+	return Decode(d)
+}
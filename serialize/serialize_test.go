@@ -0,0 +1,58 @@
+package serialize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaolion/gen/parser/parsertest"
+	"github.com/zhaolion/gen/types"
+)
+
+func TestRoundTrip(t *testing.T) {
+	universe := parsertest.Universe(t)
+
+	for _, c := range []struct {
+		name   string
+		encode func(types.Universe) ([]byte, error)
+		decode func([]byte) (types.Universe, error)
+	}{
+		{"json", EncodeJSON, DecodeJSON},
+		{"yaml", EncodeYAML, DecodeYAML},
+	} {
+		data, err := c.encode(universe)
+		if !assert.NoError(t, err, c.name) {
+			continue
+		}
+		decoded, err := c.decode(data)
+		if !assert.NoError(t, err, c.name) {
+			continue
+		}
+		assert.Equal(t, Encode(universe), Encode(decoded), c.name)
+
+		a1 := decoded.Package("github.com/zhaolion/gen/parser/testpkg/a1")
+		service := a1.Type("Service")
+		foo := service.Methods["Foo"]
+		assert.Equal(t, service, foo.Signature.Receiver, c.name)
+		assert.Equal(t, decoded.Type(types.Name{Name: "*github.com/zhaolion/gen/parser/testpkg/model.Foo"}), foo.Signature.Results[0], c.name)
+		assert.Equal(t, types.String, decoded.Package("github.com/zhaolion/gen/parser/testpkg/model").Type("Foo").Members[0].Type, c.name)
+		assert.True(t, types.Implements(decoded.Package("github.com/zhaolion/gen/parser/testpkg/a2").Type("Embedded"), service), c.name)
+		assert.Equal(t, "svc", decoded.Package("github.com/zhaolion/gen/parser/testpkg/a2").FileOf(
+			decoded.Package("github.com/zhaolion/gen/parser/testpkg/a2").Function("NewService")).Imports[0].Name, c.name)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	_, err := Decode(&Document{Version: "gen.universe/v0"})
+	assert.EqualError(t, err, `unsupported schema version "gen.universe/v0", want "gen.universe/v1"`)
+
+	_, err = Decode(&Document{
+		Version: SchemaVersion,
+		Types: []Type{{
+			Ref: "pkg.Foo", Package: "pkg", Name: "Foo", Kind: types.Pointer, Declaration: "type", Elem: "pkg.Bar",
+		}},
+	})
+	assert.EqualError(t, err, `type "pkg.Foo" references unknown type "pkg.Bar"`)
+
+	_, err = DecodeJSON([]byte("{"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,166 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/zhaolion/gen/generator"
+	"github.com/zhaolion/gen/markers"
+	"github.com/zhaolion/gen/namer"
+	"github.com/zhaolion/gen/types"
+)
+
+// FuncMap returns the functions available to the templates of g, run in c:
+//
+//	raw T                 the Go expression of T in the output package,
+//	                      importing its packages, e.g. "*model.Foo"
+//	public T, private T   an exported or unexported identifier for T, e.g.
+//	                      "PointerFoo"
+//	name "system" T       the name of T in a name system of the Context
+//	import "path"         imports a package and returns its local name
+//	markers T             the markers of type T
+//	fieldMarkers T "F"    the markers of field F of struct T
+//	methodMarkers T "M"   the markers of method M of T
+//	marker T "name"       the first marker of T with the given name, or nil
+//	hasMarker T "name"    whether T has a marker with the given name
+//	tag TAGS "key"        the value of key in the struct tags TAGS, parsed
+//	                      with types.ParseTags; malformed tags are an error
+//	tagName TAGS "key"    the value of key up to the first comma, e.g. the
+//	                      JSON name of a field
+//	comment LINES         LINES as "// " comments, without marker lines
+//	methods T             the methods of T, in source order
+//	lower, upper, join    the strings functions
+//
+// c and g may be nil when parsing templates only.
+func FuncMap(c *generator.Context, g *Generator) template.FuncMap {
+	f := &funcs{c: c, g: g}
+	var s *fileState
+	if c != nil && g != nil {
+		s = g.state(c)
+	}
+	if s != nil {
+		pkgPath := ""
+		if c.Package != nil {
+			pkgPath = c.Package.Path()
+		}
+		f.tracker = s.tracker
+		f.raw = namer.NewRawNamer(pkgPath, s.tracker)
+	}
+	f.public = namer.NewPublicNamer(0)
+	f.private = namer.NewPrivateNamer(0)
+	return template.FuncMap{
+		"raw":           func(t *types.Type) string { return f.raw.Name(t) },
+		"public":        f.public.Name,
+		"private":       f.private.Name,
+		"name":          f.name,
+		"import":        f.importPath,
+		"markers":       f.markers,
+		"fieldMarkers":  f.fieldMarkers,
+		"methodMarkers": f.methodMarkers,
+		"marker":        f.marker,
+		"hasMarker":     f.hasMarker,
+		"tag":           tag,
+		"tagName":       tagName,
+		"comment":       comment,
+		"methods":       (*types.Type).OrderedMethods,
+		"lower":         strings.ToLower,
+		"upper":         strings.ToUpper,
+		"join":          strings.Join,
+	}
+}
+
+type funcs struct {
+	c       *generator.Context
+	g       *Generator
+	tracker *namer.ImportTracker
+
+	raw, public, private namer.Namer
+}
+
+func (f *funcs) name(system string, t *types.Type) (string, error) {
+	n, ok := f.c.Namers[system]
+	if !ok {
+		return "", fmt.Errorf("unknown name system %q", system)
+	}
+	return n.Name(t), nil
+}
+
+func (f *funcs) importPath(path string) string {
+	return f.tracker.LocalNameOf(path)
+}
+
+func (f *funcs) markers(t *types.Type) (markers.Markers, error) {
+	if f.g.Markers != nil {
+		return f.g.Markers.Type(t), nil
+	}
+	return markers.Parse(t.CommentLines)
+}
+
+func (f *funcs) fieldMarkers(t *types.Type, field string) (markers.Markers, error) {
+	if f.g.Markers != nil {
+		return f.g.Markers.Field(t, field), nil
+	}
+	for _, m := range t.Members {
+		if m.Name == field {
+			return markers.Parse(append(append([]string{}, m.CommentLines...), m.TrailingCommentLines...))
+		}
+	}
+	return nil, fmt.Errorf("type %s has no field %q", t, field)
+}
+
+func (f *funcs) methodMarkers(t *types.Type, method string) (markers.Markers, error) {
+	if f.g.Markers != nil {
+		return f.g.Markers.Method(t, method), nil
+	}
+	m, ok := t.Methods[method]
+	if !ok {
+		return nil, fmt.Errorf("type %s has no method %q", t, method)
+	}
+	return markers.Parse(m.CommentLines)
+}
+
+func (f *funcs) marker(t *types.Type, name string) (*markers.Marker, error) {
+	ms, err := f.markers(t)
+	if err != nil {
+		return nil, err
+	}
+	return ms.Get(name), nil
+}
+
+func (f *funcs) hasMarker(t *types.Type, name string) (bool, error) {
+	m, err := f.marker(t, name)
+	return m != nil, err
+}
+
+func tag(tags, key string) (string, error) {
+	ts, err := types.ParseTags(tags)
+	if err != nil {
+		return "", err
+	}
+	return ts.Get(key), nil
+}
+
+func tagName(tags, key string) (string, error) {
+	ts, err := types.ParseTags(tags)
+	if err != nil {
+		return "", err
+	}
+	t, _ := ts.Lookup(key)
+	return t.Name, nil
+}
+
+func comment(lines []string) string {
+	var out []string
+	for _, line := range lines {
+		if markers.IsMarker(line) {
+			continue
+		}
+		if line == "" {
+			out = append(out, "//")
+		} else {
+			out = append(out, "// "+line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
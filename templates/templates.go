@@ -0,0 +1,194 @@
+// Package templates runs generators written as Go text/templates against the
+// Universe.
+package templates
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/zhaolion/gen/generator"
+	"github.com/zhaolion/gen/markers"
+	"github.com/zhaolion/gen/namer"
+	"github.com/zhaolion/gen/types"
+)
+
+// Generator is a generator.Generator executing a template, either once for
+// the package or once per type.
+//
+// In per-type mode, the templates named "init" and "finalize", if defined,
+// are executed before and after the types.
+type Generator struct {
+	// GeneratorName is the name of the generator, and by default of its file.
+	GeneratorName string
+
+	// OutputFilename is the name of the file to write to. If empty, it is
+	// GeneratorName with ".go" appended.
+	OutputFilename string
+
+	// PerType executes the template once per type, instead of once for the
+	// package.
+	PerType bool
+
+	// Markers are the validated markers of the Universe, used by the marker
+	// functions. If nil, markers are parsed from the comments, without
+	// validation.
+	Markers *markers.Collection
+
+	// TypeFilter filters the types. If nil, all types are accepted.
+	TypeFilter func(*generator.Context, *types.Type) bool
+
+	// Options are passed to the template as Data.Options, e.g. from a
+	// configuration file.
+	Options map[string]interface{}
+
+	template *template.Template
+}
+
+// fileState is the state of a Generator for the file being rendered, kept in
+// the generator.Context by Init.
+type fileState struct {
+	tracker *namer.ImportTracker
+	// The template with its functions bound to the Context.
+	bound *template.Template
+}
+
+// Data is passed to the templates.
+type Data struct {
+	// The generator context.
+	Context *generator.Context
+	// The output package.
+	Package generator.Package
+	// The types to generate for.
+	Types []*types.Type
+	// In per-type mode, the current type.
+	Type *types.Type
+	// The options of the generator.
+	Options map[string]interface{}
+}
+
+// New parses a template. Its functions are described in FuncMap.
+func New(name, text string) (*Generator, error) {
+	t, err := template.New(name).Funcs(FuncMap(nil, nil)).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Generator{GeneratorName: name, template: t}, nil
+}
+
+// ParseFile parses the template in the file at path. The output file is
+// named after it without the ".tmpl" extension, and the generator without
+// the file extension too, e.g. "zz_generated.deepcopy.go" and
+// "zz_generated.deepcopy" for "zz_generated.deepcopy.go.tmpl".
+func ParseFile(path string) (*Generator, error) {
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	filename := strings.TrimSuffix(filepath.Base(path), ".tmpl")
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	g, err := New(name, string(text))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	g.OutputFilename = filename
+	return g, nil
+}
+
+// Name returns GeneratorName.
+func (g *Generator) Name() string { return g.GeneratorName }
+
+// Filter calls TypeFilter, if set.
+func (g *Generator) Filter(c *generator.Context, t *types.Type) bool {
+	if g.TypeFilter != nil {
+		return g.TypeFilter(c, t)
+	}
+	return true
+}
+
+// Namers returns no name systems: the namers are template functions.
+func (g *Generator) Namers(*generator.Context) namer.NameSystems { return nil }
+
+// Imports returns the imports registered by the namers and the import
+// function.
+func (g *Generator) Imports(c *generator.Context) []string {
+	s := g.state(c)
+	if s == nil {
+		return nil
+	}
+	return s.tracker.ImportLines()
+}
+
+// Init binds the functions to c and, in per-package mode, executes the
+// template.
+func (g *Generator) Init(c *generator.Context, w io.Writer) error {
+	pkgPath := ""
+	if c.Package != nil {
+		pkgPath = c.Package.Path()
+	}
+	s := &fileState{tracker: namer.NewUniverseImportTracker(c.Universe, pkgPath)}
+	c.SetState(g, s)
+	bound, err := g.template.Clone()
+	if err != nil {
+		return err
+	}
+	s.bound = bound.Funcs(FuncMap(c, g))
+	if !g.PerType {
+		return s.bound.Execute(w, g.data(c, nil))
+	}
+	return g.executeOptional(c, w, "init", g.data(c, nil))
+}
+
+// GenerateType executes the template for t in per-type mode.
+func (g *Generator) GenerateType(c *generator.Context, t *types.Type, w io.Writer) error {
+	if !g.PerType {
+		return nil
+	}
+	return g.state(c).bound.Execute(w, g.data(c, t))
+}
+
+// Finalize executes the "finalize" template in per-type mode.
+func (g *Generator) Finalize(c *generator.Context, w io.Writer) error {
+	if !g.PerType {
+		return nil
+	}
+	return g.executeOptional(c, w, "finalize", g.data(c, nil))
+}
+
+// Filename returns OutputFilename, or GeneratorName with ".go" appended.
+func (g *Generator) Filename() string {
+	if g.OutputFilename != "" {
+		return g.OutputFilename
+	}
+	return g.GeneratorName + ".go"
+}
+
+// FileType returns generator.GolangFileType for ".go" files, and
+// generator.TextFileType otherwise.
+func (g *Generator) FileType() string {
+	if strings.HasSuffix(g.Filename(), ".go") {
+		return generator.GolangFileType
+	}
+	return generator.TextFileType
+}
+
+func (g *Generator) data(c *generator.Context, t *types.Type) *Data {
+	return &Data{Context: c, Package: c.Package, Types: c.Order, Type: t, Options: g.Options}
+}
+
+func (g *Generator) executeOptional(c *generator.Context, w io.Writer, name string, data *Data) error {
+	bound := g.state(c).bound
+	if bound.Lookup(name) == nil {
+		return nil
+	}
+	return bound.ExecuteTemplate(w, name, data)
+}
+
+// state returns the state stored in c by Init, or nil.
+func (g *Generator) state(c *generator.Context) *fileState {
+	s, _ := c.State(g).(*fileState)
+	return s
+}
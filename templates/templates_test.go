@@ -0,0 +1,183 @@
+package templates
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zhaolion/gen/generator"
+	"github.com/zhaolion/gen/parser/parsertest"
+	"github.com/zhaolion/gen/types"
+)
+
+const modelPkg = "github.com/zhaolion/gen/parser/testpkg/model"
+
+func testContext(t *testing.T) *generator.Context {
+	return generator.NewContext(parsertest.Universe(t), modelPkg)
+}
+
+func render(t *testing.T, c *generator.Context, g *Generator) string {
+	files, err := c.Render(&generator.DefaultPackage{
+		PackageName:   "out",
+		PackagePath:   "example.com/out",
+		GeneratorList: []generator.Generator{g},
+	})
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	return string(files[g.Filename()])
+}
+
+const perTypeTemplate = `{{define "init"}}// Fields by type.
+{{end}}
+{{- if and (eq .Type.Kind "Struct") .Type.IsExported}}
+{{comment .Type.CommentLines}}
+var {{private .Type}}Fields = map[string]{{raw .Type}}{
+{{- range .Type.Members}}
+	{{- $m := fieldMarkers $.Type .Name}}
+	"{{or (tagName .Tags "json") (lower .Name)}}": {}, // {{with $m.Get "gen"}}{{join .Positional " "}}{{end}}
+{{- end}}
+}
+{{end}}
+{{- define "finalize"}}
+var _ = {{import "strings"}}.ToLower
+{{end}}`
+
+func TestPerType(t *testing.T) {
+	c := testContext(t)
+	g, err := New("fields", perTypeTemplate)
+	if !assert.NoError(t, err) {
+		return
+	}
+	g.PerType = true
+	g.TypeFilter = func(c *generator.Context, t *types.Type) bool { return t.Name.Name != "Bar" }
+
+	assert.Equal(t, `package out
+
+import (
+	"github.com/zhaolion/gen/parser/testpkg/model"
+	"strings"
+)
+
+// Fields by type.
+
+// Foo obj
+var fooFields = map[string]model.Foo{
+	"tag": {}, // foo name
+}
+
+var _ = strings.ToLower
+`, render(t, c, g))
+}
+
+const perPackageTemplate = `{{range .Types}}{{if eq .Kind "Interface"}}
+// {{.Name.Name}}:{{range methods .}} {{.Signature.MethodName}} {{raw .}};{{end}}
+{{- end}}{{end}}
+`
+
+func TestPerPackage(t *testing.T) {
+	c := testContext(t)
+	g, err := New("methods", perPackageTemplate)
+	if !assert.NoError(t, err) {
+		return
+	}
+	g.OutputFilename = "methods.txt"
+	assert.Equal(t, generator.TextFileType, g.FileType())
+	assert.Equal(t, `
+// Describer: FullName func() string; ShortName func() string; Describe func() string;
+// Namer: FullName func() string; ShortName func() string;
+`, render(t, c, g))
+}
+
+func TestMarkerFuncs(t *testing.T) {
+	c := testContext(t)
+	c.Universe.Package(modelPkg).Type("Foo").CommentLines = []string{"Foo obj", "@gen deepcopy"}
+	g, err := New("markers", `{{range .Types}}{{if hasMarker . "gen"}}{{.Name.Name}} {{(marker . "gen").Positional}} {{tag (index .Members 0).Tags "json"}}{{end}}{{end}}`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	g.OutputFilename = "markers.txt"
+	assert.Equal(t, "Foo [deepcopy] tag", render(t, c, g))
+}
+
+func TestTagFuncs(t *testing.T) {
+	c := testContext(t)
+	g, err := New("tags", `{{tag .Options.tags "json"}} {{tagName .Options.tags "json"}} {{tagName .Options.tags "db"}}.`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	g.OutputFilename = "tags.txt"
+	g.Options = map[string]interface{}{"tags": `json:"name,omitempty" yaml:"name"`}
+	assert.Equal(t, "name,omitempty name .", render(t, c, g))
+
+	// Malformed tags are reported, not ignored.
+	g.Options["tags"] = `json:"name`
+	_, err = c.Render(&generator.DefaultPackage{PackagePath: "example.com/out", GeneratorList: []generator.Generator{g}})
+	assert.Error(t, err)
+}
+
+func TestSharedGenerator(t *testing.T) {
+	c := testContext(t)
+	g, err := New("dep", `var _ = {{import (printf "example.com/%s/dep" .Package.Name)}}.X`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// The same generator in two packages, with interleaved calls.
+	ca, cb := *c, *c
+	ca.Package = &generator.DefaultPackage{PackageName: "a", PackagePath: "example.com/a"}
+	cb.Package = &generator.DefaultPackage{PackageName: "b", PackagePath: "example.com/b"}
+	var wa, wb bytes.Buffer
+	assert.NoError(t, g.Init(&ca, &wa))
+	assert.NoError(t, g.Init(&cb, &wb))
+	assert.Equal(t, []string{`"example.com/a/dep"`}, g.Imports(&ca))
+	assert.Equal(t, []string{`"example.com/b/dep"`}, g.Imports(&cb))
+	assert.Equal(t, "var _ = dep.X", wa.String())
+}
+
+func TestImportMajorVersion(t *testing.T) {
+	c := testContext(t)
+	g, err := New("v1", `var _ = {{import "k8s.io/api/core/v1"}}.Pod{}`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	g.OutputFilename = "v1.go"
+	// The package is not in the Universe, so its guessed name is explicit.
+	assert.Equal(t, `package out
+
+import (
+	core "k8s.io/api/core/v1"
+)
+
+var _ = core.Pod{}
+`, render(t, c, g))
+}
+
+func TestParseFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "templates")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "zz_generated.names.go.tmpl")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{{name "upper" (index .Types 0)}}`), 0644))
+
+	g, err := ParseFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "zz_generated.names", g.Name())
+	assert.Equal(t, "zz_generated.names.go", g.Filename())
+	assert.Equal(t, generator.GolangFileType, g.FileType())
+
+	c := testContext(t)
+	_, err = c.Render(&generator.DefaultPackage{PackagePath: "example.com/out", GeneratorList: []generator.Generator{g}})
+	assert.EqualError(t, err, `generator "zz_generated.names": init: template: zz_generated.names:1:2: executing "zz_generated.names" at <name "upper" (index .Types 0)>: error calling name: unknown name system "upper"`)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{{unknown}}`), 0644))
+	_, err = ParseFile(path)
+	assert.EqualError(t, err, path+`: template: zz_generated.names:1: function "unknown" not defined`)
+}
@@ -78,28 +78,50 @@ var (
 		Name: Name{Name: "byte"},
 		Kind: Builtin,
 	}
+	Rune = &Type{
+		Name: Name{Name: "rune"},
+		Kind: Builtin,
+	}
+	Complex64 = &Type{
+		Name: Name{Name: "complex64"},
+		Kind: Builtin,
+	}
+	Complex128 = &Type{
+		Name: Name{Name: "complex128"},
+		Kind: Builtin,
+	}
+	// Any is the empty interface, predeclared as any since Go 1.18.
+	Any = &Type{
+		Name: Name{Name: "any"},
+		Kind: Interface,
+	}
 
 	builtins = &Package{
 		Types: map[string]*Type{
-			"bool":    Bool,
-			"string":  String,
-			"int":     Int,
-			"int64":   Int64,
-			"int32":   Int32,
-			"int16":   Int16,
-			"int8":    Byte,
-			"uint":    Uint,
-			"uint64":  Uint64,
-			"uint32":  Uint32,
-			"uint16":  Uint16,
-			"uint8":   Byte,
-			"uintptr": Uintptr,
-			"byte":    Byte,
-			"float":   Float,
-			"float64": Float64,
-			"float32": Float32,
+			"bool":       Bool,
+			"string":     String,
+			"int":        Int,
+			"int64":      Int64,
+			"int32":      Int32,
+			"int16":      Int16,
+			"int8":       Byte,
+			"uint":       Uint,
+			"uint64":     Uint64,
+			"uint32":     Uint32,
+			"uint16":     Uint16,
+			"uint8":      Byte,
+			"uintptr":    Uintptr,
+			"byte":       Byte,
+			"float":      Float,
+			"float64":    Float64,
+			"float32":    Float32,
+			"rune":       Rune,
+			"complex64":  Complex64,
+			"complex128": Complex128,
+			"any":        Any,
 		},
 		Imports: map[string]*Package{},
+		Files:   map[string]*File{},
 		Path:    "",
 		Name:    "",
 	}
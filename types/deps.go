@@ -0,0 +1,140 @@
+package types
+
+// Dependencies returns the types t directly depends on, in a stable order:
+// key, element and underlying types, member types, embedded interfaces and
+// the parameters and results of its signature and of its interface methods.
+// Methods declared on named types are not dependencies, since they aren't
+// part of the type's definition, and neither are receivers.
+func (t *Type) Dependencies() []*Type {
+	var result []*Type
+	add := func(ts ...*Type) {
+		for _, d := range ts {
+			if d != nil {
+				result = append(result, d)
+			}
+		}
+	}
+	addSignature := func(s *Signature) {
+		if s != nil {
+			add(s.Parameters...)
+			add(s.Results...)
+		}
+	}
+	add(t.Key, t.Elem, t.Underlying)
+	for _, m := range t.Members {
+		add(m.Type)
+	}
+	add(t.EmbeddedInterfaces...)
+	if t.Kind == Interface {
+		for _, m := range t.OrderedMethods() {
+			addSignature(m.Signature)
+		}
+	}
+	addSignature(t.Signature)
+	return result
+}
+
+// Reachable returns the roots and all types they transitively depend on,
+// across packages, in depth-first order. Every type is returned once.
+func Reachable(roots ...*Type) []*Type {
+	seen := map[*Type]bool{}
+	var result []*Type
+	var visit func(t *Type)
+	visit = func(t *Type) {
+		if seen[t] {
+			return
+		}
+		seen[t] = true
+		result = append(result, t)
+		for _, d := range t.Dependencies() {
+			visit(d)
+		}
+	}
+	for _, t := range roots {
+		if t != nil {
+			visit(t)
+		}
+	}
+	return result
+}
+
+// Component is a strongly connected component of the dependency graph: a set
+// of types which all (possibly indirectly) depend on each other.
+type Component struct {
+	// Types of the component, sorted by name.
+	Types []*Type
+	// Recursive is true if the types of the component refer to themselves,
+	// i.e. there is more than one or the only one depends on itself.
+	Recursive bool
+}
+
+// Components returns the strongly connected components of the types
+// reachable from roots, in topological order: every component comes after
+// all components it depends on.
+func Components(roots ...*Type) []Component {
+	// Tarjan's algorithm, which emits components dependencies first.
+	var (
+		index   = map[*Type]int{}
+		lowlink = map[*Type]int{}
+		onStack = map[*Type]bool{}
+		stack   []*Type
+		result  []Component
+	)
+	var connect func(t *Type)
+	connect = func(t *Type) {
+		index[t] = len(index)
+		lowlink[t] = index[t]
+		stack = append(stack, t)
+		onStack[t] = true
+
+		selfReference := false
+		for _, d := range t.Dependencies() {
+			if d == t {
+				selfReference = true
+			}
+			if _, visited := index[d]; !visited {
+				connect(d)
+				if lowlink[d] < lowlink[t] {
+					lowlink[t] = lowlink[d]
+				}
+			} else if onStack[d] && index[d] < lowlink[t] {
+				lowlink[t] = index[d]
+			}
+		}
+
+		if lowlink[t] != index[t] {
+			return
+		}
+		c := Component{}
+		for {
+			n := len(stack) - 1
+			top := stack[n]
+			stack = stack[:n]
+			onStack[top] = false
+			c.Types = append(c.Types, top)
+			if top == t {
+				break
+			}
+		}
+		c.Types = SortByName(c.Types)
+		c.Recursive = len(c.Types) > 1 || selfReference
+		result = append(result, c)
+	}
+	for _, t := range roots {
+		if _, visited := index[t]; t != nil && !visited {
+			connect(t)
+		}
+	}
+	return result
+}
+
+// TopologicalOrder returns the types reachable from roots such that every
+// type comes after the types it depends on. Types of recursive components
+// are sorted by name.
+func TopologicalOrder(roots ...*Type) []*Type {
+	var result []*Type
+	for _, c := range Components(roots...) {
+		result = append(result, c.Types...)
+	}
+	return result
+}
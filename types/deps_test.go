@@ -0,0 +1,54 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func names(ts []*Type) []string {
+	result := make([]string, 0, len(ts))
+	for _, t := range ts {
+		result = append(result, t.String())
+	}
+	return result
+}
+
+func TestDependencies(t *testing.T) {
+	u := Universe{}
+	node := u.Type(Name{Package: "pkg", Name: "Node"})
+	nodePtr := u.Type(Name{Name: "*pkg.Node"})
+	nodePtr.Kind, nodePtr.Elem = Pointer, node
+	tags := u.Type(Name{Name: "map[string]string"})
+	tags.Kind, tags.Key, tags.Elem = Map, String, String
+	node.Kind = Struct
+	node.Members = []Member{{Name: "Next", Type: nodePtr}, {Name: "Tags", Type: tags}}
+	node.Methods = map[string]*Type{
+		"Clone": {Kind: Func, Signature: &Signature{Receiver: node, MethodName: "Clone", Results: []*Type{nodePtr}}},
+	}
+
+	list := u.Type(Name{Package: "pkg", Name: "List"})
+	list.Kind = Struct
+	list.Members = []Member{{Name: "Head", Type: nodePtr}, {Name: "Len", Type: Int}}
+
+	assert.Equal(t, []string{"*pkg.Node", "map[string]string"}, names(node.Dependencies()))
+	assert.Equal(t, []string{"pkg.List", "*pkg.Node", "pkg.Node", "map[string]string", "string", "int"}, names(Reachable(list)))
+	assert.Equal(t, []string{"string", "map[string]string", "*pkg.Node", "pkg.Node", "int", "pkg.List"}, names(TopologicalOrder(list)))
+
+	components := Components(list, node)
+	assert.Len(t, components, 5)
+	assert.Equal(t, []string{"*pkg.Node", "pkg.Node"}, names(components[2].Types))
+	assert.True(t, components[2].Recursive)
+	assert.False(t, components[4].Recursive)
+
+	// An interface depends on its methods' signatures, not on the methods.
+	getter := u.Type(Name{Package: "pkg", Name: "Getter"})
+	getter.Kind = Interface
+	getter.Methods = map[string]*Type{
+		"Get": {Kind: Func, Signature: &Signature{Receiver: getter, MethodName: "Get", Parameters: []*Type{String}, Results: []*Type{getter}}},
+	}
+	assert.Equal(t, []string{"string", "pkg.Getter"}, names(getter.Dependencies()))
+	components = Components(getter)
+	assert.Len(t, components, 2)
+	assert.True(t, components[1].Recursive)
+}
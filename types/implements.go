@@ -0,0 +1,140 @@
+package types
+
+import (
+	"go/token"
+)
+
+// Implementer pairs a named type with an interface it implements.
+type Implementer struct {
+	// The named, non-interface type.
+	Type *Type
+
+	// The interface.
+	Interface *Type
+
+	// True if only the pointer type *Type implements the interface.
+	Pointer bool
+}
+
+// Implements returns true if the method set of t, including promoted
+// methods, contains all methods of the interface iface. t may be a pointer to
+// a named type.
+func Implements(t, iface *Type) bool {
+	if iface.Kind != Interface {
+		return false
+	}
+	methods := t.FullMethodSet()
+	for name, want := range iface.Methods {
+		got, ok := methods[name]
+		if !ok || got.Signature == nil || want.Signature == nil {
+			return false
+		}
+		if !token.IsExported(name) && methodPackage(got) != methodPackage(want) {
+			return false
+		}
+		if !identicalSignatures(got.Signature, want.Signature) {
+			return false
+		}
+	}
+	return true
+}
+
+// Implementers returns the named, non-interface types in u which implement
+// iface, sorted by name.
+func (u Universe) Implementers(iface *Type) []Implementer {
+	var result []Implementer
+	for _, t := range u.namedTypes() {
+		if t.Kind == Interface {
+			continue
+		}
+		if i, ok := implementation(t, iface); ok {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// ImplementedInterfaces returns the interfaces in u which t or *t implements,
+// sorted by name. Interfaces without methods are skipped, as every type
+// implements them.
+func (u Universe) ImplementedInterfaces(t *Type) []Implementer {
+	var result []Implementer
+	for _, iface := range u.namedTypes() {
+		if iface.Kind != Interface || len(iface.Methods) == 0 {
+			continue
+		}
+		if i, ok := implementation(t, iface); ok {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+func implementation(t, iface *Type) (Implementer, bool) {
+	if Implements(t, iface) {
+		return Implementer{Type: t, Interface: iface}, true
+	}
+	if Implements(&Type{Kind: Pointer, Elem: t}, iface) {
+		return Implementer{Type: t, Interface: iface, Pointer: true}, true
+	}
+	return Implementer{}, false
+}
+
+// namedTypes returns all named types in u, sorted by name.
+func (u Universe) namedTypes() []*Type {
+	var result []*Type
+	for _, p := range u {
+		for _, t := range p.Types {
+			if token.IsIdentifier(t.Name.Name) && t.Kind != Func && t.Kind != DeclarationOf {
+				result = append(result, t)
+			}
+		}
+	}
+	return SortByName(result)
+}
+
+// methodPackage returns the package a method was declared in.
+func methodPackage(m *Type) string {
+	r := m.Signature.Receiver
+	if r != nil && r.Kind == Pointer && r.Elem != nil {
+		r = r.Elem
+	}
+	if r == nil {
+		return ""
+	}
+	return r.Name.Package
+}
+
+func identicalSignatures(a, b *Signature) bool {
+	if a.Variadic != b.Variadic || len(a.Parameters) != len(b.Parameters) || len(a.Results) != len(b.Results) {
+		return false
+	}
+	for i := range a.Parameters {
+		if !identical(a.Parameters[i], b.Parameters[i]) {
+			return false
+		}
+	}
+	for i := range a.Results {
+		if !identical(a.Results[i], b.Results[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// identical returns true if a and b are the same type. Types are canonical
+// within a Universe, but names are compared as well so that types from
+// different Universes can be compared.
+func identical(a, b *Type) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+	if a.Kind == Func && b.Kind == Func && a.Signature != nil && b.Signature != nil {
+		// Parameter names are part of the name of a func type.
+		return identicalSignatures(a.Signature, b.Signature)
+	}
+	return a.Name == b.Name
+}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"fmt"
+)
+
+// InterfaceType is a view of an interface Type with helpers for templates.
+// Get one with ToInterfaceType.
+type InterfaceType Type
+
+// ToInterfaceType returns t as an InterfaceType, following aliases. It
+// returns an error if t is not an interface.
+func ToInterfaceType(t *Type) (*InterfaceType, error) {
+	if u := resolveAlias(t); u != nil && u.Kind == Interface {
+		return (*InterfaceType)(u), nil
+	}
+	return nil, fmt.Errorf("%s is %s, not an %s", t, withArticle(kindOf(t)), Interface)
+}
+
+// Type returns the underlying Type.
+func (i *InterfaceType) Type() *Type {
+	return (*Type)(i)
+}
+
+// Method returns the method with the given name, including methods of
+// embedded interfaces.
+func (i *InterfaceType) Method(name string) (*Type, bool) {
+	m, ok := i.Methods[name]
+	return m, ok
+}
+
+// OrderedMethods returns all methods, including methods of embedded
+// interfaces, in source order.
+func (i *InterfaceType) OrderedMethods() []*Type {
+	return i.Type().OrderedMethods()
+}
+
+// IsEmpty returns true if the interface has no methods.
+func (i *InterfaceType) IsEmpty() bool {
+	return len(i.Methods) == 0
+}
+
+// Embeds returns true if iface is embedded in the interface, directly or
+// through other embedded interfaces.
+func (i *InterfaceType) Embeds(iface *Type) bool {
+	for _, e := range i.EmbeddedInterfaces {
+		if e == iface || (e.Kind == Interface && (*InterfaceType)(e).Embeds(iface)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsImplementedBy returns true if t implements the interface. See
+// Implements.
+func (i *InterfaceType) IsImplementedBy(t *Type) bool {
+	return Implements(t, i.Type())
+}
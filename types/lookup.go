@@ -0,0 +1,125 @@
+package types
+
+import (
+	"fmt"
+)
+
+// LookupPackage returns the Package for the given path, if it is in u.
+// Unlike Package, it never adds a marker.
+func (u Universe) LookupPackage(packagePath string) (*Package, bool) {
+	p, ok := u[packagePath]
+	return p, ok
+}
+
+// LookupType returns the type with the given fully-qualified name, if it is
+// in u. Builtin types are always found. Unlike Type, it never adds a marker.
+func (u Universe) LookupType(n Name) (*Type, bool) {
+	if p, ok := u[n.Package]; ok {
+		return p.LookupType(n.Name)
+	}
+	if n.Package == "" {
+		t, ok := builtins.Types[n.Name]
+		return t, ok
+	}
+	return nil, false
+}
+
+// LookupFunction returns the function with the given fully-qualified name,
+// if it is in u. Unlike Function, it never adds a marker.
+func (u Universe) LookupFunction(n Name) (*Type, bool) {
+	if p, ok := u[n.Package]; ok {
+		return p.LookupFunction(n.Name)
+	}
+	return nil, false
+}
+
+// LookupVariable returns the variable with the given fully-qualified name,
+// if it is in u. Unlike Variable, it never adds a marker.
+func (u Universe) LookupVariable(n Name) (*Type, bool) {
+	if p, ok := u[n.Package]; ok {
+		return p.LookupVariable(n.Name)
+	}
+	return nil, false
+}
+
+// LookupConstant returns the constant with the given fully-qualified name,
+// if it is in u. Unlike Constant, it never adds a marker.
+func (u Universe) LookupConstant(n Name) (*Type, bool) {
+	if p, ok := u[n.Package]; ok {
+		return p.LookupConstant(n.Name)
+	}
+	return nil, false
+}
+
+// LookupType returns the type with the given name, if it is in p. Builtin
+// types are always found in the builtin package "". Unlike Type, it never
+// adds a marker.
+func (p *Package) LookupType(typeName string) (*Type, bool) {
+	if t, ok := p.Types[typeName]; ok {
+		return t, true
+	}
+	if p.Path == "" {
+		t, ok := builtins.Types[typeName]
+		return t, ok
+	}
+	return nil, false
+}
+
+// LookupFunction returns the function with the given name, if it is in p.
+// Unlike Function, it never adds a marker.
+func (p *Package) LookupFunction(funcName string) (*Type, bool) {
+	t, ok := p.Functions[funcName]
+	return t, ok
+}
+
+// LookupVariable returns the variable with the given name, if it is in p.
+// Unlike Variable, it never adds a marker.
+func (p *Package) LookupVariable(varName string) (*Type, bool) {
+	t, ok := p.Variables[varName]
+	return t, ok
+}
+
+// LookupConstant returns the constant with the given name, if it is in p.
+// Unlike Constant, it never adds a marker.
+func (p *Package) LookupConstant(constName string) (*Type, bool) {
+	t, ok := p.Constants[constName]
+	return t, ok
+}
+
+// LookupFile returns the file with the given path, if it is in p. Unlike
+// File, it never adds one.
+func (p *Package) LookupFile(filePath string) (*File, bool) {
+	f, ok := p.Files[filePath]
+	return f, ok
+}
+
+// Freeze makes u read-only: from now on, Package, Type, Function, Variable,
+// Constant, File and AddImports panic instead of adding entries, while
+// looking up existing entries keeps working. A frozen Universe can be shared
+// by concurrent generators, as long as they don't modify Types directly.
+// Freezing can't be undone; use Subset to get a mutable copy of the
+// packages.
+func (u Universe) Freeze() {
+	// The builtin package records whether u is frozen.
+	u.Package("")
+	for _, p := range u {
+		p.frozen = true
+	}
+}
+
+// Frozen returns true if u has been frozen with Freeze.
+func (u Universe) Frozen() bool {
+	p, ok := u[""]
+	return ok && p.frozen
+}
+
+// Frozen returns true if p belongs to a frozen Universe.
+func (p *Package) Frozen() bool {
+	return p.frozen
+}
+
+func (p *Package) mustNotBeFrozen(what, name string) {
+	if p.frozen {
+		panic(fmt.Sprintf("types: cannot add %s %q to package %q of a frozen Universe", what, name, p.Path))
+	}
+}
@@ -0,0 +1,76 @@
+package types
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup(t *testing.T) {
+	u := mergeTestUniverse(true)
+	u.Constant(Name{Package: "b", Name: "Max"}).Underlying = Int
+
+	foo, ok := u.LookupType(Name{Package: "a", Name: "Foo"})
+	assert.True(t, ok)
+	assert.Equal(t, "a.Foo", foo.String())
+	s, ok := u.LookupType(Name{Name: "string"})
+	assert.True(t, ok)
+	assert.Equal(t, String, s)
+	_, ok = u.LookupConstant(Name{Package: "b", Name: "Max"})
+	assert.True(t, ok)
+
+	for _, lookup := range []func() (*Type, bool){
+		func() (*Type, bool) { return u.LookupType(Name{Package: "a", Name: "Missing"}) },
+		func() (*Type, bool) { return u.LookupType(Name{Package: "missing", Name: "Foo"}) },
+		func() (*Type, bool) { return u.LookupFunction(Name{Package: "a", Name: "Foo"}) },
+		func() (*Type, bool) { return u.LookupVariable(Name{Package: "b", Name: "Max"}) },
+	} {
+		typ, ok := lookup()
+		assert.False(t, ok)
+		assert.Nil(t, typ)
+	}
+	_, ok = u.LookupPackage("missing")
+	assert.False(t, ok)
+	_, ok = u.Package("a").LookupFile("a.go")
+	assert.False(t, ok)
+
+	assert.NotContains(t, u, "missing")
+	assert.NotContains(t, u.Package("a").Types, "Missing")
+	assert.NotContains(t, u.Package("a").Files, "a.go")
+}
+
+func TestFreeze(t *testing.T) {
+	u := mergeTestUniverse(true)
+	assert.False(t, u.Frozen())
+	u.Freeze()
+	assert.True(t, u.Frozen())
+	assert.True(t, u.Package("a").Frozen())
+
+	foo := u.Type(Name{Package: "a", Name: "Foo"})
+	assert.Equal(t, Struct, foo.Kind)
+	assert.Equal(t, Int, u.Type(Name{Name: "int"}))
+	assert.NotContains(t, u[""].Types, "int")
+
+	assert.PanicsWithValue(t, `types: cannot add type "Missing" to package "a" of a frozen Universe`, func() {
+		u.Type(Name{Package: "a", Name: "Missing"})
+	})
+	assert.Panics(t, func() { u.Package("missing") })
+	assert.Panics(t, func() { u.Function(Name{Package: "a", Name: "New"}) })
+	assert.Panics(t, func() { u.Package("a").File("a.go") })
+	assert.Panics(t, func() { u.AddImports("a", "c") })
+	assert.NotPanics(t, func() { u.AddImports("a", "b") })
+	assert.Error(t, u.Merge(Universe{}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok := u.LookupType(Name{Package: "b", Name: "Bar"})
+			assert.True(t, ok)
+			assert.Equal(t, foo, u.Type(foo.Name))
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,342 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Conflict is a type, function, variable or constant which is defined
+// differently in two Universes being merged.
+type Conflict struct {
+	Name Name
+	// A description of the difference.
+	Msg string
+}
+
+func (c Conflict) Error() string {
+	return fmt.Sprintf("%s: %s", c.Name, c.Msg)
+}
+
+// MergeError is returned by Merge if the Universes conflict.
+type MergeError []Conflict
+
+func (e MergeError) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, c := range e {
+		msgs = append(msgs, c.Error())
+	}
+	return "conflicting definitions: " + strings.Join(msgs, "; ")
+}
+
+// Merge adds the packages, types, functions, variables and constants of other
+// to u. Entries of other which are already defined in u must have the same
+// shape, otherwise a MergeError listing all conflicts is returned and u is
+// left unchanged. Entries which are only markers in u (see Universe.Type) are
+// completed from other.
+//
+// The Types of other are moved into u and rewired to refer to the canonical
+// Types of u, so other must not be used after a successful Merge. A frozen
+// Universe can't be merged into.
+func (u Universe) Merge(other Universe) error {
+	if u.Frozen() {
+		return errors.New("cannot merge into a frozen Universe")
+	}
+	var conflicts MergeError
+	for _, path := range sortedPaths(other) {
+		p, ok := u[path]
+		if !ok {
+			continue
+		}
+		op := other[path]
+		for _, kind := range declarationKinds {
+			mine, theirs := kind.entries(p), kind.entries(op)
+			for _, name := range sortedNames(theirs) {
+				t, ot := mine[name], theirs[name]
+				if t == nil || t == ot || !isDefined(t) || !isDefined(ot) {
+					continue
+				}
+				if msg := compareShape(t, ot); msg != "" {
+					conflicts = append(conflicts, Conflict{Name: Name{Package: path, Name: name}, Msg: kind.what + " " + msg})
+				}
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return conflicts
+	}
+
+	m := &merger{u: u, canonical: map[*Type]*Type{}}
+	for _, path := range sortedPaths(other) {
+		m.mergePackage(other[path])
+	}
+	for len(m.queue) > 0 {
+		t := m.queue[0]
+		m.queue = m.queue[1:]
+		m.rewire(t)
+	}
+	for _, path := range sortedPaths(other) {
+		m.mergeFiles(other[path])
+	}
+	return nil
+}
+
+type declarationKind struct {
+	what    string
+	entries func(*Package) map[string]*Type
+}
+
+var declarationKinds = []declarationKind{
+	{"type", func(p *Package) map[string]*Type { return p.Types }},
+	{"function", func(p *Package) map[string]*Type { return p.Functions }},
+	{"variable", func(p *Package) map[string]*Type { return p.Variables }},
+	{"constant", func(p *Package) map[string]*Type { return p.Constants }},
+}
+
+type merger struct {
+	u Universe
+	// canonical maps the Types of the other Universe to the Types of u.
+	canonical map[*Type]*Type
+	// Types moved into u whose references still need to be rewired.
+	queue []*Type
+}
+
+func (m *merger) mergePackage(op *Package) {
+	p := m.u.Package(op.Path)
+	if p.Name == "" {
+		p.Name = op.Name
+	}
+	if p.SourcePath == "" {
+		p.SourcePath = op.SourcePath
+	}
+	if len(p.DocComments) == 0 {
+		p.DocComments = op.DocComments
+	}
+	if len(p.Comments) == 0 {
+		p.Comments = op.Comments
+	}
+	for path := range op.Imports {
+		p.Imports[path] = m.u.Package(path)
+	}
+	for _, kind := range declarationKinds {
+		mine, theirs := kind.entries(p), kind.entries(op)
+		for _, name := range sortedNames(theirs) {
+			ot := theirs[name]
+			t, ok := mine[name]
+			switch {
+			case !ok:
+				mine[name] = ot
+				m.adopt(ot)
+			case t == ot:
+			case !isDefined(t) && isDefined(ot):
+				// Complete the marker in place, as Types of u refer to it.
+				*t = *ot
+				m.canonical[ot] = t
+				m.queue = append(m.queue, t)
+			default:
+				m.canonical[ot] = t
+			}
+		}
+	}
+}
+
+// adopt moves t into u as is.
+func (m *merger) adopt(t *Type) {
+	if _, ok := m.canonical[t]; ok {
+		return
+	}
+	m.canonical[t] = t
+	m.queue = append(m.queue, t)
+}
+
+// resolve returns the canonical Type for t, adopting Types which aren't
+// registered in any package, like the Func types of methods.
+func (m *merger) resolve(t *Type) *Type {
+	if t == nil {
+		return nil
+	}
+	if c, ok := m.canonical[t]; ok {
+		return c
+	}
+	m.adopt(t)
+	return t
+}
+
+func (m *merger) rewire(t *Type) {
+	t.Key = m.resolve(t.Key)
+	t.Elem = m.resolve(t.Elem)
+	t.Underlying = m.resolve(t.Underlying)
+	if t.Members != nil {
+		members := make([]Member, len(t.Members))
+		copy(members, t.Members)
+		for i := range members {
+			members[i].Type = m.resolve(members[i].Type)
+		}
+		t.Members = members
+	}
+	t.Methods = m.resolveMap(t.Methods)
+	t.ExplicitMethods = m.resolveMap(t.ExplicitMethods)
+	t.EmbeddedInterfaces = m.resolveSlice(t.EmbeddedInterfaces)
+	if s := t.Signature; s != nil {
+		c := *s
+		c.Receiver = m.resolve(s.Receiver)
+		c.Parameters = m.resolveSlice(s.Parameters)
+		c.Results = m.resolveSlice(s.Results)
+		t.Signature = &c
+	}
+}
+
+func (m *merger) resolveMap(ts map[string]*Type) map[string]*Type {
+	if ts == nil {
+		return nil
+	}
+	result := make(map[string]*Type, len(ts))
+	for name, t := range ts {
+		result[name] = m.resolve(t)
+	}
+	return result
+}
+
+func (m *merger) resolveSlice(ts []*Type) []*Type {
+	if ts == nil {
+		return nil
+	}
+	result := make([]*Type, len(ts))
+	for i, t := range ts {
+		result[i] = m.resolve(t)
+	}
+	return result
+}
+
+func (m *merger) mergeFiles(op *Package) {
+	p := m.u[op.Path]
+	for path, of := range op.Files {
+		if _, ok := p.Files[path]; ok {
+			continue
+		}
+		f := *of
+		f.Package = p
+		f.Imports = make([]*Import, 0, len(of.Imports))
+		for _, i := range of.Imports {
+			c := *i
+			c.Package = m.u.Package(i.Path)
+			f.Imports = append(f.Imports, &c)
+		}
+		f.Objects = make([]*Type, 0, len(of.Objects))
+		for _, t := range of.Objects {
+			f.Objects = append(f.Objects, m.resolve(t))
+		}
+		p.Files[path] = &f
+	}
+}
+
+// isDefined returns false for the markers created by looking up unknown
+// names.
+func isDefined(t *Type) bool {
+	if t.Kind == DeclarationOf {
+		return t.Underlying != nil
+	}
+	return t.Kind != Unknown
+}
+
+// compareShape returns a description of the first difference between a and
+// b, or "" if they have the same shape. Referenced types are compared by
+// name.
+func compareShape(a, b *Type) string {
+	if a.Kind != b.Kind {
+		return fmt.Sprintf("kind %s differs from %s", a.Kind, b.Kind)
+	}
+	for _, ref := range []struct {
+		what string
+		a, b *Type
+	}{
+		{"key", a.Key, b.Key},
+		{"element", a.Elem, b.Elem},
+		{"underlying type", a.Underlying, b.Underlying},
+	} {
+		if refName(ref.a) != refName(ref.b) {
+			return fmt.Sprintf("%s %s differs from %s", ref.what, refName(ref.a), refName(ref.b))
+		}
+	}
+	if memberShape(a.Members) != memberShape(b.Members) {
+		return fmt.Sprintf("fields {%s} differ from {%s}", memberShape(a.Members), memberShape(b.Members))
+	}
+	if methodShape(a.Methods) != methodShape(b.Methods) {
+		return fmt.Sprintf("methods {%s} differ from {%s}", methodShape(a.Methods), methodShape(b.Methods))
+	}
+	if signatureShape(a.Signature) != signatureShape(b.Signature) {
+		return fmt.Sprintf("signature %s differs from %s", signatureShape(a.Signature), signatureShape(b.Signature))
+	}
+	return ""
+}
+
+func refName(t *Type) string {
+	if t == nil {
+		return "<nil>"
+	}
+	return t.Name.String()
+}
+
+func memberShape(members []Member) string {
+	fields := make([]string, 0, len(members))
+	for _, m := range members {
+		s := m.Name + " " + refName(m.Type)
+		if m.Embedded {
+			s = refName(m.Type)
+		}
+		if m.Tags != "" {
+			s += " `" + m.Tags + "`"
+		}
+		fields = append(fields, s)
+	}
+	return strings.Join(fields, "; ")
+}
+
+func methodShape(methods map[string]*Type) string {
+	result := make([]string, 0, len(methods))
+	for _, name := range sortedNames(methods) {
+		result = append(result, name+signatureShape(methods[name].Signature))
+	}
+	return strings.Join(result, "; ")
+}
+
+func signatureShape(s *Signature) string {
+	if s == nil {
+		return ""
+	}
+	params := make([]string, 0, len(s.Parameters))
+	for _, p := range s.Parameters {
+		params = append(params, refName(p))
+	}
+	if s.Variadic && len(params) > 0 {
+		params[len(params)-1] = "..." + params[len(params)-1]
+	}
+	results := make([]string, 0, len(s.Results))
+	for _, r := range s.Results {
+		results = append(results, refName(r))
+	}
+	out := "(" + strings.Join(params, ", ") + ")"
+	if s.PointerReceiver {
+		out = "*" + out
+	}
+	return out + " (" + strings.Join(results, ", ") + ")"
+}
+
+func sortedPaths(u Universe) []string {
+	paths := make([]string, 0, len(u))
+	for path := range u {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func sortedNames(ts map[string]*Type) []string {
+	names := make([]string, 0, len(ts))
+	for name := range ts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
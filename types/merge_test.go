@@ -0,0 +1,91 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mergeTestUniverse(withBar bool) Universe {
+	u := Universe{}
+	bar := u.Type(Name{Package: "b", Name: "Bar"})
+	if withBar {
+		bar.Kind = Struct
+		bar.Members = []Member{{Name: "Name", Type: String}}
+		bar.Methods = map[string]*Type{
+			"String": {Kind: Func, Signature: &Signature{Receiver: bar, MethodName: "String", Results: []*Type{String}}},
+		}
+		u.Package("b").Name = "b"
+	}
+	barPtr := u.Type(Name{Name: "*b.Bar"})
+	barPtr.Kind, barPtr.Elem = Pointer, bar
+
+	foo := u.Type(Name{Package: "a", Name: "Foo"})
+	foo.Kind = Struct
+	foo.Members = []Member{{Name: "Bar", Type: barPtr, Tags: `json:"bar"`}}
+	u.Package("a").Name = "a"
+	u.AddImports("a", "b")
+	return u
+}
+
+func TestMerge(t *testing.T) {
+	u := mergeTestUniverse(false)
+	bar := u.Type(Name{Package: "b", Name: "Bar"})
+	assert.Equal(t, Unknown, bar.Kind)
+
+	other := mergeTestUniverse(true)
+	other.Variable(Name{Package: "b", Name: "Default"}).Underlying = other.Type(Name{Package: "b", Name: "Bar"})
+	assert.NoError(t, u.Merge(other))
+
+	// The marker is completed in place and the new entries refer to it.
+	assert.Equal(t, bar, u.Type(Name{Package: "b", Name: "Bar"}))
+	assert.Equal(t, Struct, bar.Kind)
+	assert.Equal(t, "b", u.Package("b").Name)
+	assert.Equal(t, bar, u.Package("b").Variables["Default"].Underlying)
+	assert.Equal(t, bar, bar.Methods["String"].Signature.Receiver)
+	assert.Equal(t, bar, u.Type(Name{Package: "a", Name: "Foo"}).Members[0].Type.Elem)
+
+	conflicting := mergeTestUniverse(true)
+	conflicting.Type(Name{Package: "a", Name: "Foo"}).Members[0].Tags = `json:"b"`
+	conflicting.Type(Name{Package: "b", Name: "Bar"}).Kind = Interface
+	err := u.Merge(conflicting)
+	if assert.Error(t, err) {
+		assert.Len(t, err.(MergeError), 2)
+		assert.Equal(t, Name{Package: "a", Name: "Foo"}, err.(MergeError)[0].Name)
+		assert.Contains(t, err.Error(), "b.Bar: type kind Struct differs from Interface")
+	}
+	assert.Equal(t, Struct, bar.Kind)
+}
+
+func TestSubset(t *testing.T) {
+	u := mergeTestUniverse(true)
+	other := u.Type(Name{Package: "b", Name: "Other"})
+	other.Kind = Struct
+	f := u.Package("a").File("/src/a/a.go")
+	f.Objects = []*Type{u.Type(Name{Package: "a", Name: "Foo"})}
+	f.Imports = []*Import{
+		{Path: "b", Package: u.Package("b")},
+		{Path: "fmt", Package: u.Package("fmt")},
+	}
+
+	s := u.Subset("a")
+	assert.Equal(t, []string{"", "a", "b"}, sortedPaths(s))
+	assert.Equal(t, []string{"Bar"}, sortedNames(s["b"].Types))
+	assert.Equal(t, []string{"*b.Bar", "string"}, sortedNames(s[""].Types))
+	assert.Equal(t, u.Type(Name{Package: "b", Name: "Bar"}), s["b"].Types["Bar"])
+	assert.Contains(t, s["a"].Imports, "b")
+	sf := s["a"].File("/src/a/a.go")
+	assert.Equal(t, s["a"], sf.Package)
+	if assert.Len(t, sf.Imports, 2) {
+		assert.True(t, s["b"] == sf.Imports[0].Package)
+		assert.Nil(t, sf.Imports[1].Package)
+	}
+	// The source Universe is left as it was.
+	assert.True(t, u["b"] == f.Imports[0].Package)
+	assert.True(t, u["fmt"] == f.Imports[1].Package)
+	assert.Empty(t, s["b"].Files)
+
+	s = u.SubsetOf(other)
+	assert.Equal(t, []string{"b"}, sortedPaths(s))
+	assert.Equal(t, []string{"Other"}, sortedNames(s["b"].Types))
+}
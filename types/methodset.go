@@ -0,0 +1,73 @@
+package types
+
+// ValueMethods returns the methods of t which are in the method set of the
+// value type: all methods of an interface, otherwise the methods with a
+// value receiver. Promoted methods are not included.
+func (t *Type) ValueMethods() map[string]*Type {
+	result := map[string]*Type{}
+	for name, m := range t.Methods {
+		if t.Kind == Interface || m.Signature == nil || !m.Signature.PointerReceiver {
+			result[name] = m
+		}
+	}
+	return result
+}
+
+// PointerMethods returns the methods of t which are in the method set of
+// the pointer type *t, i.e. all methods. Promoted methods are not included.
+func (t *Type) PointerMethods() map[string]*Type {
+	result := make(map[string]*Type, len(t.Methods))
+	for name, m := range t.Methods {
+		result[name] = m
+	}
+	return result
+}
+
+// MethodSet returns the declared methods which may be called on a value of
+// type t. For a pointer to a named type, this is the pointer method set of
+// the named type. Promoted methods are not included.
+func (t *Type) MethodSet() map[string]*Type {
+	if t.Kind == Pointer && t.Elem != nil {
+		if t.Elem.Kind == Interface {
+			// Pointers to interfaces have no methods.
+			return map[string]*Type{}
+		}
+		return t.Elem.PointerMethods()
+	}
+	return t.ValueMethods()
+}
+
+// FullMethodSet returns the method set of t, including methods promoted
+// through embedded fields, following Go's selector rules: a method declared
+// at a shallower depth shadows deeper ones, and names found more than once at
+// the same depth are ambiguous and left out. t may be a pointer to a named
+// type.
+func (t *Type) FullMethodSet() map[string]*Type {
+	result := map[string]*Type{}
+	selections, _ := t.selections()
+	for _, s := range selections {
+		if s.inMethodSet() {
+			result[s.Name] = s.Method
+		}
+	}
+	return result
+}
+
+// MethodOrigin returns the interface which declares the named method of
+// interface t: t itself if the method is explicit, otherwise the embedded
+// interface it was (possibly indirectly) embedded from. Returns nil if t has
+// no such method.
+func (t *Type) MethodOrigin(name string) *Type {
+	if _, ok := t.Methods[name]; !ok {
+		return nil
+	}
+	if _, ok := t.ExplicitMethods[name]; ok || len(t.EmbeddedInterfaces) == 0 {
+		return t
+	}
+	for _, e := range t.EmbeddedInterfaces {
+		if origin := e.MethodOrigin(name); origin != nil {
+			return origin
+		}
+	}
+	return t
+}
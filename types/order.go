@@ -0,0 +1,110 @@
+package types
+
+import (
+	"sort"
+)
+
+// OrderedTypes returns the types of this package in source order.
+func (p *Package) OrderedTypes() []*Type {
+	return SortBySource(typeValues(p.Types))
+}
+
+// SortedTypes returns the types of this package sorted by name.
+func (p *Package) SortedTypes() []*Type {
+	return SortByName(typeValues(p.Types))
+}
+
+// OrderedFunctions returns the functions of this package in source order.
+func (p *Package) OrderedFunctions() []*Type {
+	return SortBySource(typeValues(p.Functions))
+}
+
+// SortedFunctions returns the functions of this package sorted by name.
+func (p *Package) SortedFunctions() []*Type {
+	return SortByName(typeValues(p.Functions))
+}
+
+// OrderedVariables returns the variables of this package in source order.
+func (p *Package) OrderedVariables() []*Type {
+	return SortBySource(typeValues(p.Variables))
+}
+
+// SortedVariables returns the variables of this package sorted by name.
+func (p *Package) SortedVariables() []*Type {
+	return SortByName(typeValues(p.Variables))
+}
+
+// OrderedConstants returns the constants of this package in source order.
+func (p *Package) OrderedConstants() []*Type {
+	return SortBySource(typeValues(p.Constants))
+}
+
+// SortedConstants returns the constants of this package sorted by name.
+func (p *Package) SortedConstants() []*Type {
+	return SortByName(typeValues(p.Constants))
+}
+
+// Declarations returns all types, functions, variables and constants of this
+// package in source order.
+func (p *Package) Declarations() []*Type {
+	result := make([]*Type, 0, len(p.Types)+len(p.Functions)+len(p.Variables)+len(p.Constants))
+	result = append(result, typeValues(p.Types)...)
+	result = append(result, typeValues(p.Functions)...)
+	result = append(result, typeValues(p.Variables)...)
+	result = append(result, typeValues(p.Constants)...)
+	return SortBySource(result)
+}
+
+// OrderedMethods returns the methods of this type in source order.
+func (t *Type) OrderedMethods() []*Type {
+	return SortBySource(typeValues(t.Methods))
+}
+
+// SortedMethods returns the methods of this type sorted by method name.
+func (t *Type) SortedMethods() []*Type {
+	names := t.MethodNames()
+	result := make([]*Type, 0, len(names))
+	for _, n := range names {
+		result = append(result, t.Methods[n])
+	}
+	return result
+}
+
+// MethodNames returns the names of the methods of this type, sorted.
+func (t *Type) MethodNames() []string {
+	names := make([]string, 0, len(t.Methods))
+	for n := range t.Methods {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SortBySource sorts ts in place by declaration position and returns it.
+// Types without a known position come last, sorted by name.
+func SortBySource(ts []*Type) []*Type {
+	sort.SliceStable(ts, func(i, j int) bool {
+		pi, pj := ts[i].Position, ts[j].Position
+		if !pi.IsValid() && !pj.IsValid() {
+			return ts[i].Name.String() < ts[j].Name.String()
+		}
+		return pi.Before(pj)
+	})
+	return ts
+}
+
+// SortByName sorts ts in place by name and returns it.
+func SortByName(ts []*Type) []*Type {
+	sort.SliceStable(ts, func(i, j int) bool {
+		return ts[i].Name.String() < ts[j].Name.String()
+	})
+	return ts
+}
+
+func typeValues(m map[string]*Type) []*Type {
+	result := make([]*Type, 0, len(m))
+	for _, t := range m {
+		result = append(result, t)
+	}
+	return result
+}
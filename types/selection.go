@@ -0,0 +1,217 @@
+package types
+
+import (
+	"sort"
+	"strings"
+)
+
+// Selection is a field or method reachable from a type through the selector
+// expression x.Name, either declared on the type itself or promoted through
+// embedded fields.
+type Selection struct {
+	// The field or method name.
+	Name string
+
+	// If a field, the field. Nil for methods.
+	Field *Member
+
+	// If a method, the method. Nil for fields.
+	Method *Type
+
+	// The embedded fields traversed to reach the selection, outermost first.
+	// Empty if the field or method is declared on the type itself.
+	Path []Member
+
+	// True if a pointer is dereferenced on the way: the selection is made on
+	// a pointer, or one of the embedded fields in Path is a pointer.
+	Indirect bool
+}
+
+// Depth returns the number of embedded fields traversed.
+func (s Selection) Depth() int {
+	return len(s.Path)
+}
+
+// IsPromoted returns true if the field or method is promoted through
+// embedded fields.
+func (s Selection) IsPromoted() bool {
+	return len(s.Path) > 0
+}
+
+// IsMethod returns true if the selection is a method.
+func (s Selection) IsMethod() bool {
+	return s.Method != nil
+}
+
+// Selector returns the full selector without the operand, spelling out the
+// embedded fields, e.g. "Entry.Foo".
+func (s Selection) Selector() string {
+	parts := make([]string, 0, len(s.Path)+1)
+	for _, m := range s.Path {
+		parts = append(parts, m.Name)
+	}
+	return strings.Join(append(parts, s.Name), ".")
+}
+
+// inMethodSet returns true if the selected method is in the method set of
+// the type the selection was made on.
+func (s Selection) inMethodSet() bool {
+	return s.Method != nil && (s.Indirect || s.Method.Signature == nil || !s.Method.Signature.PointerReceiver)
+}
+
+// Selections returns all fields and methods of t, including those promoted
+// through embedded fields, ordered by depth, then fields in member order
+// before methods in source order. Ambiguous names are left out, see
+// AmbiguousSelectors.
+func (t *Type) Selections() []Selection {
+	selections, _ := t.selections()
+	return selections
+}
+
+// AmbiguousSelectors returns the names which are found more than once at the
+// shallowest depth they occur at, and are therefore not valid selectors of
+// t, sorted.
+func (t *Type) AmbiguousSelectors() []string {
+	_, ambiguous := t.selections()
+	return ambiguous
+}
+
+// LookupSelection returns the field or method of t with the given name,
+// including promoted ones.
+func (t *Type) LookupSelection(name string) (Selection, bool) {
+	for _, s := range t.Selections() {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Selection{}, false
+}
+
+// AllFields returns all fields of t, including promoted ones.
+func (t *Type) AllFields() []Selection {
+	var result []Selection
+	for _, s := range t.Selections() {
+		if s.Field != nil {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// AllMethods returns all methods which may be selected on t, including
+// promoted ones. Unlike FullMethodSet, this includes methods with a pointer
+// receiver which can only be called on addressable values.
+func (t *Type) AllMethods() []Selection {
+	var result []Selection
+	for _, s := range t.Selections() {
+		if s.Method != nil {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// selections resolves the fields and methods of t, including those promoted
+// through embedded fields, following Go's selector rules: a name found at a
+// shallower depth shadows deeper ones, and names found more than once at the
+// same depth are ambiguous and left out, and returned as the second result.
+// t may be a pointer to a named type. Selections are ordered by depth, then
+// fields in member order before methods in source order.
+func (t *Type) selections() ([]Selection, []string) {
+	root, pointer := t, false
+	if t.Kind == Pointer && t.Elem != nil {
+		if t.Elem.Kind == Interface {
+			// Pointers to interfaces have no methods.
+			return nil, nil
+		}
+		root, pointer = t.Elem, true
+	}
+
+	type embedded struct {
+		typ      *Type
+		path     []Member
+		indirect bool
+	}
+
+	var (
+		result    []Selection
+		ambiguous []string
+		// Names found at a shallower depth.
+		blocked = map[string]bool{}
+		seen    = map[*Type]bool{}
+		current = []embedded{{typ: root, indirect: pointer}}
+	)
+	for len(current) > 0 {
+		var (
+			found = map[string][]Selection{}
+			order []string
+			next  []embedded
+		)
+		add := func(s Selection) {
+			if _, ok := found[s.Name]; !ok {
+				order = append(order, s.Name)
+			}
+			found[s.Name] = append(found[s.Name], s)
+		}
+		for _, e := range current {
+			if seen[e.typ] {
+				continue
+			}
+			if e.typ.Kind == Struct {
+				for i := range e.typ.Members {
+					m := &e.typ.Members[i]
+					add(Selection{Name: m.Name, Field: m, Path: e.path, Indirect: e.indirect})
+					if !m.Embedded || m.Type == nil {
+						continue
+					}
+					typ, indirect := m.Type, e.indirect
+					if typ.Kind == Pointer && typ.Elem != nil {
+						typ, indirect = typ.Elem, true
+					}
+					path := append(append([]Member{}, e.path...), *m)
+					next = append(next, embedded{typ: typ, path: path, indirect: indirect})
+				}
+			}
+			for _, name := range orderedMethodNames(e.typ) {
+				add(Selection{Name: name, Method: e.typ.Methods[name], Path: e.path, Indirect: e.indirect})
+			}
+		}
+		// Types found more than once at this depth are fine to process
+		// twice; their names end up ambiguous.
+		for _, e := range current {
+			seen[e.typ] = true
+		}
+
+		for _, name := range order {
+			if blocked[name] {
+				continue
+			}
+			blocked[name] = true
+			if candidates := found[name]; len(candidates) == 1 {
+				result = append(result, candidates[0])
+			} else {
+				ambiguous = append(ambiguous, name)
+			}
+		}
+		current = next
+	}
+
+	// Fields before methods at each depth, keeping the order otherwise.
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Depth() != result[j].Depth() {
+			return result[i].Depth() < result[j].Depth()
+		}
+		return result[i].Field != nil && result[j].Field == nil
+	})
+	sort.Strings(ambiguous)
+	return result, ambiguous
+}
+
+// orderedMethodNames returns the names of the methods of t in source order.
+func orderedMethodNames(t *Type) []string {
+	names := t.MethodNames()
+	sort.SliceStable(names, func(i, j int) bool {
+		return t.Methods[names[i]].Position.Before(t.Methods[names[j]].Position)
+	})
+	return names
+}
@@ -0,0 +1,63 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelections(t *testing.T) {
+	u := Universe{}
+	inner := u.Type(Name{Package: "pkg", Name: "Inner"})
+	inner.Kind = Struct
+	inner.Members = []Member{{Name: "ID", Type: Int}, {Name: "Name", Type: String}}
+	inner.Methods = map[string]*Type{
+		"Get": {Kind: Func, Signature: &Signature{Receiver: inner, MethodName: "Get"}, Position: Position{Line: 1}},
+		"Set": {Kind: Func, Signature: &Signature{Receiver: inner, MethodName: "Set", PointerReceiver: true}, Position: Position{Line: 2}},
+	}
+	other := u.Type(Name{Package: "pkg", Name: "Other"})
+	other.Kind = Struct
+	other.Members = []Member{{Name: "Name", Type: String}}
+	otherPtr := &Type{Name: Name{Name: "*pkg.Other"}, Kind: Pointer, Elem: other}
+
+	outer := u.Type(Name{Package: "pkg", Name: "Outer"})
+	outer.Kind = Struct
+	outer.Members = []Member{
+		{Name: "Inner", Embedded: true, Type: inner},
+		{Name: "Other", Embedded: true, Type: otherPtr},
+		{Name: "ID", Type: String},
+	}
+
+	var selectors []string
+	for _, s := range outer.Selections() {
+		selectors = append(selectors, s.Selector())
+	}
+	assert.Equal(t, []string{"Inner", "Other", "ID", "Inner.Get", "Inner.Set"}, selectors)
+	assert.Equal(t, []string{"Name"}, outer.AmbiguousSelectors())
+
+	id, ok := outer.LookupSelection("ID")
+	assert.True(t, ok)
+	assert.False(t, id.IsPromoted())
+	assert.Equal(t, String, id.Field.Type)
+
+	set, ok := outer.LookupSelection("Set")
+	assert.True(t, ok)
+	assert.True(t, set.IsMethod())
+	assert.Equal(t, 1, set.Depth())
+	assert.False(t, set.Indirect)
+	_, ok = outer.LookupSelection("Name")
+	assert.False(t, ok)
+
+	assert.Len(t, outer.AllFields(), 3)
+	assert.Len(t, outer.AllMethods(), 2)
+	assert.Equal(t, []string{"Get"}, (&Type{Methods: outer.FullMethodSet()}).MethodNames())
+	outerPtr := &Type{Name: Name{Name: "*pkg.Outer"}, Kind: Pointer, Elem: outer}
+	assert.Equal(t, []string{"Get", "Set"}, (&Type{Methods: outerPtr.FullMethodSet()}).MethodNames())
+
+	// Through the embedded pointer, Name is promoted from Other alone.
+	viaOther := &Type{Kind: Struct, Members: []Member{{Name: "Other", Embedded: true, Type: otherPtr}}}
+	name, ok := viaOther.LookupSelection("Name")
+	assert.True(t, ok)
+	assert.True(t, name.Indirect)
+	assert.Equal(t, "Other.Name", name.Selector())
+}
@@ -0,0 +1,235 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StructType is a view of a struct Type with helpers for templates. Get one
+// with ToStructType.
+type StructType Type
+
+// ToStructType returns t as a StructType, following aliases. It returns an
+// error if t is not a struct.
+func ToStructType(t *Type) (*StructType, error) {
+	if u := resolveAlias(t); u != nil && u.Kind == Struct {
+		return (*StructType)(u), nil
+	}
+	return nil, fmt.Errorf("%s is %s, not a %s", t, withArticle(kindOf(t)), Struct)
+}
+
+// Type returns the underlying Type.
+func (s *StructType) Type() *Type {
+	return (*Type)(s)
+}
+
+// Field returns the declared field with the given name. See
+// Type.LookupSelection for promoted fields.
+func (s *StructType) Field(name string) (*Member, bool) {
+	for i := range s.Members {
+		if s.Members[i].Name == name {
+			return &s.Members[i], true
+		}
+	}
+	return nil, false
+}
+
+// FieldByTag returns the first declared field whose tag with the given key
+// has the given name, e.g. FieldByTag("json", "id") for `json:"id"`. Fields
+// with malformed tags are skipped.
+func (s *StructType) FieldByTag(key, name string) (*Member, bool) {
+	for i := range s.Members {
+		tags, err := s.Members[i].ParseTags()
+		if err != nil {
+			continue
+		}
+		if t, ok := tags.Lookup(key); ok && t.Name == name {
+			return &s.Members[i], true
+		}
+	}
+	return nil, false
+}
+
+// Fields returns the declared fields which pass all filters, in order.
+func (s *StructType) Fields(filters ...FieldFilter) []Member {
+	var result []Member
+	for _, m := range s.Members {
+		if matchField(m, filters) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// AllFields returns the fields, including promoted ones, which pass all
+// filters. See Type.Selections.
+func (s *StructType) AllFields(filters ...FieldFilter) []Selection {
+	var result []Selection
+	for _, sel := range s.Type().AllFields() {
+		if matchField(*sel.Field, filters) {
+			result = append(result, sel)
+		}
+	}
+	return result
+}
+
+// Method returns the method with the given name declared on the struct.
+func (s *StructType) Method(name string) (*Type, bool) {
+	m, ok := s.Methods[name]
+	return m, ok
+}
+
+// IsComparable returns true if values of the struct can be compared with ==.
+func (s *StructType) IsComparable() bool {
+	return s.Type().IsComparable()
+}
+
+// FieldFilter selects struct fields, see StructType.Fields.
+type FieldFilter func(m Member) bool
+
+var (
+	// ExportedFields selects exported fields.
+	ExportedFields FieldFilter = func(m Member) bool { return m.IsExported() }
+	// EmbeddedFields selects embedded fields.
+	EmbeddedFields FieldFilter = func(m Member) bool { return m.Embedded }
+)
+
+// FieldsWithTag selects fields with a tag with the given key.
+func FieldsWithTag(key string) FieldFilter {
+	return func(m Member) bool {
+		tags, err := m.ParseTags()
+		if err != nil {
+			return false
+		}
+		_, ok := tags.Lookup(key)
+		return ok
+	}
+}
+
+// FieldsOfKind selects fields whose type has one of the given kinds.
+func FieldsOfKind(kinds ...Kind) FieldFilter {
+	return func(m Member) bool {
+		for _, k := range kinds {
+			if m.Type != nil && m.Type.Kind == k {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// NotFields negates a filter.
+func NotFields(f FieldFilter) FieldFilter {
+	return func(m Member) bool { return !f(m) }
+}
+
+func matchField(m Member, filters []FieldFilter) bool {
+	for _, f := range filters {
+		if !f(m) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsComparable returns true if values of type t can be compared with ==.
+func (t *Type) IsComparable() bool {
+	return isComparable(t, map[*Type]bool{})
+}
+
+func isComparable(t *Type, visiting map[*Type]bool) bool {
+	if t == nil {
+		return false
+	}
+	if visiting[t] {
+		// Only reachable through an invalid recursive type; don't loop.
+		return true
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind {
+	case Builtin, Pointer, Chan, Interface, Unsupported:
+		return true
+	case Map, Slice, Func, Unknown:
+		return false
+	case Array:
+		return isComparable(t.Elem, visiting)
+	case Alias, DeclarationOf:
+		return isComparable(t.Underlying, visiting)
+	case Struct:
+		for _, m := range t.Members {
+			if !isComparable(m.Type, visiting) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// IsNilable returns true if nil is the zero value of type t.
+func (t *Type) IsNilable() bool {
+	switch u := resolveAlias(t); {
+	case u == nil:
+		return false
+	case u.Kind == Pointer, u.Kind == Map, u.Kind == Slice, u.Kind == Chan, u.Kind == Func, u.Kind == Interface:
+		return true
+	}
+	return false
+}
+
+// ZeroValue returns a Go expression for the zero value of type t. Named
+// struct and array types are written with their unqualified name, e.g.
+// "Foo{}".
+func (t *Type) ZeroValue() string {
+	if t.IsNilable() {
+		return "nil"
+	}
+	u := resolveAlias(t)
+	if u == nil {
+		return ""
+	}
+	switch u.Kind {
+	case Struct, Array:
+		return t.Name.Name + "{}"
+	case Builtin, Unsupported:
+		switch u.Name.Name {
+		case "string":
+			return `""`
+		case "bool":
+			return "false"
+		}
+		return "0"
+	}
+	return ""
+}
+
+// resolveAlias follows Alias types to the first non-alias type.
+func resolveAlias(t *Type) *Type {
+	for i := 0; t != nil && t.Kind == Alias; i++ {
+		if i > 100 {
+			return nil
+		}
+		t = t.Underlying
+	}
+	return t
+}
+
+func kindOf(t *Type) Kind {
+	if t == nil {
+		return Unknown
+	}
+	return t.Kind
+}
+
+// withArticle returns k preceded by "a" or "an", e.g. "an Interface".
+func withArticle(k Kind) string {
+	if k == Unknown {
+		return "of unknown kind"
+	}
+	if strings.ContainsAny(string(k)[:1], "AEIOU") {
+		return "an " + string(k)
+	}
+	return "a " + string(k)
+}
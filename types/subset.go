@@ -0,0 +1,109 @@
+package types
+
+// Subset returns a Universe with all types, functions, variables and
+// constants of the given packages, plus everything they depend on. See
+// SubsetOf.
+func (u Universe) Subset(packagePaths ...string) Universe {
+	var roots []*Type
+	for _, path := range packagePaths {
+		if p, ok := u[path]; ok {
+			roots = append(roots, p.Declarations()...)
+		}
+	}
+	result := u.SubsetOf(roots...)
+	for _, path := range packagePaths {
+		p, ok := u[path]
+		if !ok {
+			continue
+		}
+		rp := result.Package(path)
+		for filePath, f := range p.Files {
+			c := *f
+			c.Package = rp
+			// Point the imports at the packages of the subset, or at
+			// nothing if it doesn't have the imported package.
+			c.Imports = make([]*Import, 0, len(f.Imports))
+			for _, i := range f.Imports {
+				ic := *i
+				ic.Package = result[i.Path]
+				c.Imports = append(c.Imports, &ic)
+			}
+			rp.Files[filePath] = &c
+		}
+	}
+	return result
+}
+
+// SubsetOf returns a Universe with the given types, functions, variables and
+// constants, plus everything they depend on, including the methods of named
+// types and what their signatures depend on.
+//
+// The returned Universe has its own Packages, holding the subset of the
+// entries and imports of the packages of u, but shares the Types with u.
+// Files are only included in the result of Subset, for the packages asked
+// for; their imports refer to the packages of the subset.
+func (u Universe) SubsetOf(roots ...*Type) Universe {
+	result := Universe{}
+	seen := map[*Type]bool{}
+	var visit func(t *Type)
+	visit = func(t *Type) {
+		if t == nil || seen[t] {
+			return
+		}
+		seen[t] = true
+		u.addTo(result, t)
+		for _, d := range t.Dependencies() {
+			visit(d)
+		}
+		if t.Kind != Interface {
+			for _, m := range t.OrderedMethods() {
+				seen[m] = true
+				u.addTo(result, m)
+				if s := m.Signature; s != nil {
+					for _, d := range append(append([]*Type{}, s.Parameters...), s.Results...) {
+						visit(d)
+					}
+				}
+			}
+		}
+	}
+	for _, t := range roots {
+		visit(t)
+	}
+
+	for path, p := range result {
+		orig, ok := u[path]
+		if !ok {
+			continue
+		}
+		p.Name = orig.Name
+		p.SourcePath = orig.SourcePath
+		p.DocComments = orig.DocComments
+		p.Comments = orig.Comments
+		for i := range orig.Imports {
+			if _, ok := result[i]; ok {
+				p.Imports[i] = result[i]
+			}
+		}
+	}
+	return result
+}
+
+// addTo registers t in the package of result that holds it in u, if any.
+// Builtins are always registered.
+func (u Universe) addTo(result Universe, t *Type) {
+	if t.Name.Package == "" && builtins.Types[t.Name.Name] == t {
+		result.Package("").Type(t.Name.Name)
+		return
+	}
+	p, ok := u[t.Name.Package]
+	if !ok {
+		return
+	}
+	for _, kind := range declarationKinds {
+		if kind.entries(p)[t.Name.Name] == t {
+			kind.entries(result.Package(p.Path))[t.Name.Name] = t
+			return
+		}
+	}
+}
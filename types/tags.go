@@ -0,0 +1,163 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Tag is a single key:"value" pair of a struct tag. The value is split into
+// a name and options the way encoding/json does, e.g. `json:"name,omitempty"`
+// has Name "name" and Options ["omitempty"].
+type Tag struct {
+	Key     string
+	Name    string
+	Options []string
+}
+
+// Value returns the value of the tag, as it was written between the quotes.
+func (t Tag) Value() string {
+	if len(t.Options) == 0 {
+		return t.Name
+	}
+	return t.Name + "," + strings.Join(t.Options, ",")
+}
+
+// HasOption returns true if the tag has the given option.
+func (t Tag) HasOption(option string) bool {
+	for _, o := range t.Options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the tag in key:"value" form.
+func (t Tag) String() string {
+	return t.Key + ":" + strconv.Quote(t.Value())
+}
+
+// Tags is a parsed struct tag, in source order.
+type Tags []Tag
+
+// ParseTags parses a struct tag, e.g. `json:"name,omitempty" db:"name"`,
+// following the conventions of reflect.StructTag. Unlike reflect.StructTag,
+// malformed tags and duplicate keys are reported as errors.
+func ParseTags(tag string) (Tags, error) {
+	var tags Tags
+	seen := map[string]bool{}
+	for tag != "" {
+		// Skip leading space.
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+
+		// Scan to colon. A space, a quote or a control character is a syntax
+		// error.
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return nil, fmt.Errorf("bad syntax for struct tag pair at %q", tag)
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// Scan quoted string to find value.
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return nil, fmt.Errorf("bad syntax for struct tag value of %q", key)
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			return nil, fmt.Errorf("bad syntax for struct tag value of %q: %v", key, err)
+		}
+		tag = tag[i+1:]
+		if tag != "" && tag[0] != ' ' {
+			return nil, fmt.Errorf("missing space after struct tag value of %q", key)
+		}
+
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate struct tag key %q", key)
+		}
+		seen[key] = true
+
+		parts := strings.Split(value, ",")
+		t := Tag{Key: key, Name: parts[0]}
+		if len(parts) > 1 {
+			t.Options = parts[1:]
+		}
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
+
+// Lookup returns the tag with the given key.
+func (ts Tags) Lookup(key string) (Tag, bool) {
+	for _, t := range ts {
+		if t.Key == key {
+			return t, true
+		}
+	}
+	return Tag{}, false
+}
+
+// Get returns the value of the tag with the given key, or "" if there is
+// none.
+func (ts Tags) Get(key string) string {
+	t, _ := ts.Lookup(key)
+	return t.Value()
+}
+
+// Keys returns the keys of the tags, in order.
+func (ts Tags) Keys() []string {
+	keys := make([]string, 0, len(ts))
+	for _, t := range ts {
+		keys = append(keys, t.Key)
+	}
+	return keys
+}
+
+// Set replaces the tag with the same key, or adds it at the end.
+func (ts *Tags) Set(tag Tag) {
+	for i := range *ts {
+		if (*ts)[i].Key == tag.Key {
+			(*ts)[i] = tag
+			return
+		}
+	}
+	*ts = append(*ts, tag)
+}
+
+// Delete removes the tag with the given key, if any.
+func (ts *Tags) Delete(key string) {
+	for i := range *ts {
+		if (*ts)[i].Key == key {
+			*ts = append((*ts)[:i], (*ts)[i+1:]...)
+			return
+		}
+	}
+}
+
+// String renders the tags back into struct tag form, without the enclosing
+// back quotes.
+func (ts Tags) String() string {
+	parts := make([]string, 0, len(ts))
+	for _, t := range ts {
+		parts = append(parts, t.String())
+	}
+	return strings.Join(parts, " ")
+}
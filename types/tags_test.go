@@ -0,0 +1,50 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTags(t *testing.T) {
+	tags, err := ParseTags(`json:"name,omitempty,string" db:"name"  yaml:",inline" validate:"a\"b"`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"json", "db", "yaml", "validate"}, tags.Keys())
+
+	json, ok := tags.Lookup("json")
+	assert.True(t, ok)
+	assert.Equal(t, "name", json.Name)
+	assert.Equal(t, []string{"omitempty", "string"}, json.Options)
+	assert.True(t, json.HasOption("omitempty"))
+	assert.False(t, json.HasOption("inline"))
+
+	yaml, _ := tags.Lookup("yaml")
+	assert.Equal(t, "", yaml.Name)
+	assert.True(t, yaml.HasOption("inline"))
+	assert.Equal(t, `a"b`, tags.Get("validate"))
+	assert.Equal(t, "", tags.Get("xml"))
+
+	tags.Set(Tag{Key: "db", Name: "full_name"})
+	tags.Set(Tag{Key: "xml", Name: "name", Options: []string{"attr"}})
+	tags.Delete("validate")
+	assert.Equal(t, `json:"name,omitempty,string" db:"full_name" yaml:",inline" xml:"name,attr"`, tags.String())
+
+	for _, tag := range []string{
+		`json:"a" json:"b"`,
+		`json:"a"db:"b"`,
+		`json:a`,
+		`json:"a`,
+		`:"a"`,
+		`json "a"`,
+	} {
+		_, err := ParseTags(tag)
+		assert.Error(t, err, tag)
+	}
+
+	m := Member{Name: "Name", Tags: `json:"tag"`}
+	tags, err = m.ParseTags()
+	assert.NoError(t, err)
+	assert.Equal(t, "tag", tags.Get("json"))
+}
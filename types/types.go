@@ -16,6 +16,13 @@ limitations under the License.
 
 package types
 
+import (
+	"fmt"
+	"go/token"
+	"path"
+	"path/filepath"
+)
+
 // Universe is a map of all packages. The key is the package name, but you
 // should use Package(), Type(), Function(), or Variable() instead of direct
 // access.
@@ -58,6 +65,9 @@ func (u Universe) Constant(n Name) *Type {
 func (u Universe) AddImports(packagePath string, importPaths ...string) {
 	p := u.Package(packagePath)
 	for _, i := range importPaths {
+		if _, ok := p.Imports[i]; !ok {
+			p.mustNotBeFrozen("import", i)
+		}
 		p.Imports[i] = u.Package(i)
 	}
 }
@@ -70,6 +80,9 @@ func (u Universe) Package(packagePath string) *Package {
 	if p, ok := u[packagePath]; ok {
 		return p
 	}
+	if u.Frozen() {
+		panic(fmt.Sprintf("types: cannot add package %q to a frozen Universe", packagePath))
+	}
 	p := &Package{
 		Path:      packagePath,
 		Types:     map[string]*Type{},
@@ -77,6 +90,7 @@ func (u Universe) Package(packagePath string) *Package {
 		Variables: map[string]*Type{},
 		Constants: map[string]*Type{},
 		Imports:   map[string]*Package{},
+		Files:     map[string]*File{},
 	}
 	u[packagePath] = p
 	return p
@@ -121,6 +135,12 @@ type Package struct {
 	// Packages imported by this package, indexed by (canonicalized)
 	// package path.
 	Imports map[string]*Package
+
+	// Files of this package, indexed by their absolute path.
+	Files map[string]*File
+
+	// If true, adding entries panics. See Universe.Freeze.
+	frozen bool
 }
 
 // Has returns true if the given name references a type known to this package.
@@ -139,10 +159,13 @@ func (p *Package) Type(typeName string) *Type {
 	if p.Path == "" {
 		// Import the standard builtin types!
 		if t, ok := builtins.Types[typeName]; ok {
-			p.Types[typeName] = t
+			if !p.frozen {
+				p.Types[typeName] = t
+			}
 			return t
 		}
 	}
+	p.mustNotBeFrozen("type", typeName)
 	t := &Type{Name: Name{Package: p.Path, Name: typeName}}
 	p.Types[typeName] = t
 	return t
@@ -156,6 +179,7 @@ func (p *Package) Function(funcName string) *Type {
 	if t, ok := p.Functions[funcName]; ok {
 		return t
 	}
+	p.mustNotBeFrozen("function", funcName)
 	t := &Type{Name: Name{Package: p.Path, Name: funcName}}
 	t.Kind = DeclarationOf
 	p.Functions[funcName] = t
@@ -170,6 +194,7 @@ func (p *Package) Variable(varName string) *Type {
 	if t, ok := p.Variables[varName]; ok {
 		return t
 	}
+	p.mustNotBeFrozen("variable", varName)
 	t := &Type{Name: Name{Package: p.Path, Name: varName}}
 	t.Kind = DeclarationOf
 	p.Variables[varName] = t
@@ -184,12 +209,39 @@ func (p *Package) Constant(constName string) *Type {
 	if t, ok := p.Constants[constName]; ok {
 		return t
 	}
+	p.mustNotBeFrozen("constant", constName)
 	t := &Type{Name: Name{Package: p.Path, Name: constName}}
 	t.Kind = DeclarationOf
 	p.Constants[constName] = t
 	return t
 }
 
+// File gets the given File in this Package. If the File is not already
+// defined, this will add it. If a File is added, it's the caller's
+// responsibility to finish construction of the file.
+func (p *Package) File(filePath string) *File {
+	if f, ok := p.Files[filePath]; ok {
+		return f
+	}
+	p.mustNotBeFrozen("file", filePath)
+	f := &File{Path: filePath, Package: p}
+	p.Files[filePath] = f
+	return f
+}
+
+// FileOf returns the File that declares the given package-level type,
+// function, variable or constant, or nil if it is not declared in p.
+func (p *Package) FileOf(t *Type) *File {
+	for _, f := range p.Files {
+		for _, obj := range f.Objects {
+			if obj == t {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
 // HasImport returns true if p imports packageName. Package names include the
 // package directory.
 func (p *Package) HasImport(packageName string) bool {
@@ -197,7 +249,8 @@ func (p *Package) HasImport(packageName string) bool {
 	return has
 }
 
-// Interfaces return all interface types in this package.
+// Interfaces return all interface types in this package, sorted by name. Pass
+// them to SortBySource for source order.
 func (p *Package) Interfaces() []*Type {
 	interfaces := make([]*Type, 0, len(p.Types))
 	for k, v := range p.Types {
@@ -206,10 +259,11 @@ func (p *Package) Interfaces() []*Type {
 		}
 	}
 
-	return interfaces
+	return SortByName(interfaces)
 }
 
-// Structs return all struct types in this package.
+// Structs return all struct types in this package, sorted by name. Pass them
+// to SortBySource for source order.
 func (p *Package) Structs() []*Type {
 	result := make([]*Type, 0, len(p.Types))
 	for k, v := range p.Types {
@@ -218,14 +272,78 @@ func (p *Package) Structs() []*Type {
 		}
 	}
 
-	return result
+	return SortByName(result)
 }
 
-// TODO: support more useful functions
-type StructType Type
+// File holds file-level information.
+type File struct {
+	// Absolute path of the file.
+	Path string
+
+	// The package this file belongs to.
+	Package *Package
+
+	// Build constraint lines (`//go:build` and `// +build`) of the file, as
+	// written.
+	BuildConstraints []string
+
+	// The comment right above the package clause, if any.
+	DocComments []string
 
-// TODO: support more useful functions
-type InterfaceType Type
+	// Imports of this file, in source order.
+	Imports []*Import
+
+	// Package-level types, functions, variables and constants declared in
+	// this file, in source order. Methods are found on their receiver types.
+	Objects []*Type
+}
+
+// Name returns the base name of the file.
+func (f *File) Name() string {
+	return path.Base(filepath.ToSlash(f.Path))
+}
+
+// Dir returns the directory of the file.
+func (f *File) Dir() string {
+	return filepath.Dir(f.Path)
+}
+
+// Import returns the import of the given package path in this file, or nil.
+func (f *File) Import(packagePath string) *Import {
+	for _, i := range f.Imports {
+		if i.Path == packagePath {
+			return i
+		}
+	}
+	return nil
+}
+
+// Import is a single import spec of a file.
+type Import struct {
+	// Canonical path of the imported package.
+	Path string
+
+	// The local name as written in the import spec, e.g. "foo" in
+	// `import foo "github.com/x/bar"`, or "_" and "." for blank and dot
+	// imports. Empty if the import has no explicit name.
+	Name string
+
+	// The imported package.
+	Package *Package
+}
+
+// LocalName returns the name the imported package is referred to by in the
+// importing file: the explicit name if there is one, otherwise the package
+// name, falling back to the last element of the path.
+func (i *Import) LocalName() string {
+	if i.Name != "" {
+		return i.Name
+	}
+	if i.Package != nil && i.Package.Name != "" {
+		return i.Package.Name
+	}
+	return path.Base(i.Path)
+}
 
 // Type represents a subset of possible go types.
 type Type struct {
@@ -263,6 +381,20 @@ type Type struct {
 	// ---
 	SecondClosestCommentLines []string
 
+	// If there is a comment on the same line, after the declaration, it will
+	// be recorded here.
+	TrailingCommentLines []string
+
+	// If the declaration is part of a parenthesized group, e.g.
+	// ---
+	// GroupCommentLines
+	// const (
+	//	A = 1
+	// )
+	// ---
+	// the comment lines immediately before the group will be recorded here.
+	GroupCommentLines []string
+
 	// If Kind == Struct
 	Members []Member
 
@@ -281,9 +413,21 @@ type Type struct {
 	// type has. (All elements will have Kind=="Func")
 	Methods map[string]*Type
 
+	// If Kind == Interface, these are the methods declared in the interface
+	// itself, i.e. Methods without the ones from EmbeddedInterfaces.
+	ExplicitMethods map[string]*Type
+
+	// If Kind == Interface, these are the interfaces embedded in it, in
+	// source order.
+	EmbeddedInterfaces []*Type
+
 	// If Kind == func, this is the signature of the function.
 	Signature *Signature
 
+	// If this is a named type, a method, or a top-level function, variable
+	// or constant, the location of its declaration. Not valid otherwise.
+	Position Position
+
 	// TODO: Add:
 	// * channel direction
 	// * array length
@@ -294,6 +438,24 @@ func (t *Type) String() string {
 	return t.Name.String()
 }
 
+// IsExported returns true if t is a method, a named type or a top-level
+// declaration with an exported name.
+func (t *Type) IsExported() bool {
+	if t.Kind == Func && t.Signature != nil && t.Signature.MethodName != "" {
+		return token.IsExported(t.Signature.MethodName)
+	}
+	if t.Name.Package == "" {
+		// Builtin or anonymous.
+		return false
+	}
+	return token.IsExported(t.Name.Name)
+}
+
+// IsMethod returns true if t is the Func type of a method.
+func (t *Type) IsMethod() bool {
+	return t.Kind == Func && t.Signature != nil && t.Signature.Receiver != nil
+}
+
 // Signature is a function's signature.
 type Signature struct {
 	// TODO: store the parameter names, not just types.
@@ -306,6 +468,12 @@ type Signature struct {
 	// True if the last in parameter is of the form ...T.
 	Variadic bool
 
+	// If a method, the name of the method.
+	MethodName string
+
+	// If a method, true if it has a pointer receiver, e.g. func (e *Entry) Foo().
+	PointerReceiver bool
+
 	// If there are comment lines immediately before this
 	// signature/method/function declaration, they will be recorded here.
 	CommentLines []string
@@ -324,11 +492,18 @@ type Member struct {
 	// definition, they will be recorded here.
 	CommentLines []string
 
+	// If there is a comment on the same line, after the member, it will be
+	// recorded here.
+	TrailingCommentLines []string
+
 	// If there are tags along with this member, they will be saved here.
 	Tags string
 
 	// The type of this member.
 	Type *Type
+
+	// The location of the member in the type definition.
+	Position Position
 }
 
 // String returns the name and type of the member.
@@ -336,6 +511,55 @@ func (m Member) String() string {
 	return m.Name + " " + m.Type.String()
 }
 
+// IsExported returns true if the member has an exported name.
+func (m Member) IsExported() bool {
+	return token.IsExported(m.Name)
+}
+
+// ParseTags parses the tags of the member. See ParseTags.
+func (m Member) ParseTags() (Tags, error) {
+	return ParseTags(m.Tags)
+}
+
+// Position is a location in a source file.
+type Position struct {
+	// Absolute path of the file.
+	Filename string
+	// Byte offset, starting at 0.
+	Offset int
+	// Line number, starting at 1.
+	Line int
+	// Column number (byte count), starting at 1.
+	Column int
+}
+
+// IsValid returns true if the position is known.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// Before returns true if p comes before o in source order. Positions in
+// different files are ordered by file name, and unknown positions come last.
+func (p Position) Before(o Position) bool {
+	switch {
+	case !p.IsValid():
+		return false
+	case !o.IsValid():
+		return true
+	case p.Filename != o.Filename:
+		return p.Filename < o.Filename
+	}
+	return p.Offset < o.Offset
+}
+
+// String returns the position formatted as file:line:column.
+func (p Position) String() string {
+	if !p.IsValid() {
+		return "-"
+	}
+	return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+}
+
 // A type name may have a package qualifier.
 type Name struct {
 	// Empty if embedded or builtin. This is the package path unless Path is specified.
@@ -0,0 +1,197 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Problem is an inconsistency found by Validate.
+type Problem struct {
+	// The type, function, variable, constant, field or method with the
+	// problem, e.g. "github.com/x/model.Foo.Bar".
+	Object string
+
+	// Where Object is declared, if known.
+	Position Position
+
+	Msg string
+}
+
+func (p Problem) Error() string {
+	if p.Position.IsValid() {
+		return fmt.Sprintf("%s: %s: %s", p.Position, p.Object, p.Msg)
+	}
+	return fmt.Sprintf("%s: %s", p.Object, p.Msg)
+}
+
+// ValidationError lists the problems found by Validate.
+type ValidationError []Problem
+
+func (e ValidationError) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, p := range e {
+		msgs = append(msgs, p.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Validate checks the consistency of u and returns a ValidationError if it
+// finds:
+//   - dangling markers: entries created by looking up a name which was never
+//     defined, i.e. types of Kind Unknown and declarations without Underlying
+//   - types of Kind Unknown or Unsupported reachable from the given packages
+//   - functions and methods without a Signature
+//   - broken references: entries registered under a different name, and
+//     missing key, element, underlying, field, parameter or result types
+//
+// If no packages are given, the packages loaded from source (i.e. having
+// Files) are checked for reachable problems.
+func (u Universe) Validate(packagePaths ...string) error {
+	v := &validator{reported: map[*Type]bool{}, checked: map[*Type]bool{}}
+
+	for _, path := range sortedPaths(u) {
+		p := u[path]
+		for _, kind := range declarationKinds {
+			entries := kind.entries(p)
+			for _, name := range sortedNames(entries) {
+				t := entries[name]
+				switch {
+				case t == nil:
+					v.add(path+"."+name, Position{}, "nil %s", kind.what)
+				case path == "" && builtins.Types[name] == t:
+					// Builtin aliases, like byte for uint8.
+				case t.Name.Package != path || t.Name.Name != name:
+					v.report(t, "%s registered as %s", kind.what, Name{Package: path, Name: name})
+				case !isDefined(t):
+					v.report(t, "dangling %s marker: never defined", kind.what)
+				}
+			}
+		}
+	}
+
+	if len(packagePaths) == 0 {
+		for _, path := range sortedPaths(u) {
+			if len(u[path].Files) > 0 {
+				packagePaths = append(packagePaths, path)
+			}
+		}
+	}
+	for _, path := range packagePaths {
+		p, ok := u[path]
+		if !ok {
+			v.add(path, Position{}, "package not found")
+			continue
+		}
+		for _, t := range p.Declarations() {
+			v.check(t, nil)
+		}
+	}
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return v.problems
+}
+
+type validator struct {
+	problems ValidationError
+	// Types with a problem already reported.
+	reported map[*Type]bool
+	checked  map[*Type]bool
+}
+
+func (v *validator) add(object string, pos Position, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{Object: object, Position: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) report(t *Type, format string, args ...interface{}) {
+	v.reported[t] = true
+	v.add(t.String(), t.Position, format, args...)
+}
+
+// check checks t, referenced by from, and everything reachable from it.
+func (v *validator) check(t, from *Type) {
+	if v.checked[t] {
+		return
+	}
+	v.checked[t] = true
+
+	if (t.Kind == Unknown || t.Kind == Unsupported) && !v.reported[t] {
+		kind := "unknown"
+		if t.Kind == Unsupported {
+			kind = "unsupported"
+		}
+		if from != nil {
+			v.report(t, "%s type referenced by %s", kind, from)
+		} else {
+			v.report(t, "%s type", kind)
+		}
+	}
+
+	object := t.String()
+	ref := func(what string, r *Type) {
+		if r == nil && v.reported[t] {
+			return
+		}
+		if r == nil {
+			v.add(object, t.Position, "missing %s", what)
+			return
+		}
+		v.check(r, t)
+	}
+	switch t.Kind {
+	case Map:
+		ref("key type", t.Key)
+		ref("element type", t.Elem)
+	case Pointer, Slice, Array, Chan:
+		ref("element type", t.Elem)
+	case Alias, DeclarationOf:
+		ref("underlying type", t.Underlying)
+	case Func:
+		if t.Signature == nil {
+			v.add(object, t.Position, "missing signature")
+		}
+	}
+	for _, m := range t.Members {
+		if m.Type == nil {
+			v.add(object+"."+m.Name, m.Position, "missing field type")
+			continue
+		}
+		v.check(m.Type, t)
+	}
+	for _, e := range t.EmbeddedInterfaces {
+		ref("embedded interface", e)
+	}
+	for _, name := range sortedNames(t.Methods) {
+		m := t.Methods[name]
+		if m == nil {
+			v.add(object+"."+name, Position{}, "missing method")
+			continue
+		}
+		if m.Signature == nil {
+			v.add(object+"."+name, m.Position, "missing signature")
+			continue
+		}
+		v.checkSignature(object+"."+name, m.Position, m.Signature, t)
+	}
+	if t.Signature != nil {
+		v.checkSignature(object, t.Position, t.Signature, t)
+	}
+}
+
+func (v *validator) checkSignature(object string, pos Position, s *Signature, from *Type) {
+	for i, p := range s.Parameters {
+		if p == nil {
+			v.add(object, pos, "missing type of parameter %d", i)
+			continue
+		}
+		v.check(p, from)
+	}
+	for i, r := range s.Results {
+		if r == nil {
+			v.add(object, pos, "missing type of result %d", i)
+			continue
+		}
+		v.check(r, from)
+	}
+}
@@ -0,0 +1,54 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	u := mergeTestUniverse(true)
+	u.Package("a").File("/src/a/a.go")
+	assert.NoError(t, u.Validate())
+
+	foo := u.Type(Name{Package: "a", Name: "Foo"})
+	foo.Members = append(foo.Members, Member{Name: "Baz", Type: u.Type(Name{Package: "c", Name: "Baz"})}, Member{Name: "Qux"})
+	foo.Methods = map[string]*Type{"Do": {Kind: Func, Position: Position{Filename: "/src/a/a.go", Line: 3, Column: 1}}}
+	u.Function(Name{Package: "a", Name: "New"})
+	u.Package("b").Types["Alias"] = &Type{Name: Name{Package: "b", Name: "Bar"}, Kind: Alias, Underlying: String}
+
+	err := u.Validate()
+	if assert.Error(t, err) {
+		var msgs []string
+		for _, p := range err.(ValidationError) {
+			msgs = append(msgs, p.Error())
+		}
+		assert.Equal(t, []string{
+			"a.New: dangling function marker: never defined",
+			"b.Bar: type registered as b.Alias",
+			"c.Baz: dangling type marker: never defined",
+			"a.Foo.Qux: missing field type",
+			"/src/a/a.go:3:1: a.Foo.Do: missing signature",
+		}, msgs)
+	}
+
+	// Unknown types are reported once, by the first check that finds them.
+	u = mergeTestUniverse(false)
+	u.Package("a").File("/src/a/a.go")
+	u.Package("b").Types = map[string]*Type{}
+	err = u.Validate()
+	if assert.Error(t, err) {
+		assert.Equal(t, "b.Bar: unknown type referenced by *b.Bar", err.Error())
+	}
+
+	u = mergeTestUniverse(true)
+	u.Package("a").File("/src/a/a.go")
+	foo = u.Type(Name{Package: "a", Name: "Foo"})
+	unsafePointer := u.Type(Name{Name: "unsafe.Pointer"})
+	unsafePointer.Kind = Unsupported
+	foo.Members = append(foo.Members, Member{Name: "P", Type: unsafePointer}, Member{Name: "R", Type: u.Type(Name{Name: "rune"})})
+	err = u.Validate()
+	if assert.Error(t, err) {
+		assert.Equal(t, "unsafe.Pointer: unsupported type referenced by a.Foo", err.Error())
+	}
+}
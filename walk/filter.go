@@ -0,0 +1,147 @@
+package walk
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/zhaolion/gen/markers"
+	"github.com/zhaolion/gen/types"
+)
+
+// Filter selects named types. It is parsed from an expression of
+// space-separated terms, all of which must match:
+//
+//	package=github.com/x/model/... kind=struct,interface name=*Service !marker=skip tag=json
+//
+// A term is "field=pattern[,pattern...]" and matches if any pattern does; a
+// leading '!' negates it. The fields are:
+//
+//	package  the package path, as a glob; a trailing "/..." also matches subpackages
+//	kind     the kind of the type, case insensitive
+//	name     the name of the type without package, as a glob
+//	marker   the name of a marker (see package markers) in the type's comments
+//	tag      the key of a struct tag on any of the type's fields
+type Filter struct {
+	expr  string
+	terms []term
+}
+
+type term struct {
+	field    string
+	patterns []string
+	negate   bool
+}
+
+// ParseFilter parses a filter expression. The empty expression matches all
+// types.
+func ParseFilter(expr string) (*Filter, error) {
+	f := &Filter{expr: expr}
+	for _, s := range strings.Fields(expr) {
+		t := term{}
+		if strings.HasPrefix(s, "!") {
+			t.negate, s = true, s[1:]
+		}
+		i := strings.Index(s, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid filter term %q: want field=pattern", s)
+		}
+		t.field = s[:i]
+		switch t.field {
+		case "package", "kind", "name", "marker", "tag":
+		default:
+			return nil, fmt.Errorf("invalid filter term %q: unknown field %q", s, t.field)
+		}
+		for _, p := range strings.Split(s[i+1:], ",") {
+			if p == "" {
+				return nil, fmt.Errorf("invalid filter term %q: empty pattern", s)
+			}
+			if t.field == "package" || t.field == "name" {
+				if _, err := path.Match(p, ""); err != nil {
+					return nil, fmt.Errorf("invalid filter term %q: bad pattern %q", s, p)
+				}
+			}
+			t.patterns = append(t.patterns, p)
+		}
+		f.terms = append(f.terms, t)
+	}
+	return f, nil
+}
+
+// String returns the expression the filter was parsed from.
+func (f *Filter) String() string {
+	return f.expr
+}
+
+// Match returns true if t matches all terms of the filter.
+func (f *Filter) Match(t *types.Type) bool {
+	for _, term := range f.terms {
+		if term.match(t) == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (t term) match(typ *types.Type) bool {
+	for _, p := range t.patterns {
+		if t.matchPattern(typ, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t term) matchPattern(typ *types.Type, p string) bool {
+	switch t.field {
+	case "package":
+		if strings.HasSuffix(p, "/...") {
+			base := strings.TrimSuffix(p, "/...")
+			return typ.Name.Package == base || strings.HasPrefix(typ.Name.Package, base+"/")
+		}
+		ok, _ := path.Match(p, typ.Name.Package)
+		return ok
+	case "kind":
+		return strings.EqualFold(string(typ.Kind), p)
+	case "name":
+		ok, _ := path.Match(p, typ.Name.Name)
+		return ok
+	case "marker":
+		for _, line := range typ.CommentLines {
+			if !markers.IsMarker(line) {
+				continue
+			}
+			if m, err := markers.ParseLine(line); err == nil && m.Name == p {
+				return true
+			}
+		}
+	case "tag":
+		for _, m := range typ.Members {
+			tags, err := m.ParseTags()
+			if err != nil {
+				continue
+			}
+			if _, ok := tags.Lookup(p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Select returns the named types of u matching f, sorted by name.
+func Select(u types.Universe, f *Filter) []*types.Type {
+	var result []*types.Type
+	for _, p := range u {
+		for name, t := range p.Types {
+			if p.Path == "" || t.Name.Name != name || t.IsMethod() {
+				// Builtins, anonymous types and methods.
+				continue
+			}
+			if f.Match(t) {
+				result = append(result, t)
+			}
+		}
+	}
+	return types.SortByName(result)
+}
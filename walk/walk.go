@@ -0,0 +1,123 @@
+package walk
+
+import (
+	"sort"
+
+	"github.com/zhaolion/gen/types"
+)
+
+// Visitor is called for every type reached by Walk.
+type Visitor interface {
+	// Pre is called before the types referenced by t are walked. If it
+	// returns false, they are skipped, and Post is not called for t.
+	Pre(t *types.Type) bool
+
+	// Post is called after the types referenced by t were walked.
+	Post(t *types.Type)
+}
+
+// Funcs is a Visitor made of optional hooks. Hooks for the kind of a type
+// are called after the Any hooks.
+type Funcs struct {
+	PreAny  func(t *types.Type) bool
+	PostAny func(t *types.Type)
+
+	PreKind  map[types.Kind]func(t *types.Type) bool
+	PostKind map[types.Kind]func(t *types.Type)
+}
+
+// Pre implements Visitor.
+func (f Funcs) Pre(t *types.Type) bool {
+	if f.PreAny != nil && !f.PreAny(t) {
+		return false
+	}
+	if pre, ok := f.PreKind[t.Kind]; ok {
+		return pre(t)
+	}
+	return true
+}
+
+// Post implements Visitor.
+func (f Funcs) Post(t *types.Type) {
+	if f.PostAny != nil {
+		f.PostAny(t)
+	}
+	if post, ok := f.PostKind[t.Kind]; ok {
+		post(t)
+	}
+}
+
+// Walker walks types, visiting every type at most once, so it is safe to use
+// on recursive types. Reuse a Walker to visit each type once across several
+// walks.
+type Walker struct {
+	visitor Visitor
+	seen    map[*types.Type]bool
+}
+
+// NewWalker constructs a Walker calling v.
+func NewWalker(v Visitor) *Walker {
+	return &Walker{visitor: v, seen: map[*types.Type]bool{}}
+}
+
+// Walk visits t and, depth first, the types it references: elements, keys,
+// underlying types, members, methods, embedded interfaces, and parameters
+// and results of signatures. Receivers are not followed.
+func (w *Walker) Walk(t *types.Type) {
+	if t == nil || w.seen[t] {
+		return
+	}
+	w.seen[t] = true
+	if !w.visitor.Pre(t) {
+		return
+	}
+	for _, child := range Children(t) {
+		w.Walk(child)
+	}
+	w.visitor.Post(t)
+}
+
+// Walk visits t and the types it references with v. See Walker.Walk.
+func Walk(t *types.Type, v Visitor) {
+	NewWalker(v).Walk(t)
+}
+
+// Universe visits, with v, the named types, functions, variables and
+// constants of all packages of u, in package path and then source order, and
+// the types they reference. Every type is visited once.
+func Universe(u types.Universe, v Visitor) {
+	w := NewWalker(v)
+	paths := make([]string, 0, len(u))
+	for path := range u {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for _, t := range u[path].Declarations() {
+			w.Walk(t)
+		}
+	}
+}
+
+// Children returns the types directly referenced by t, in a stable order.
+func Children(t *types.Type) []*types.Type {
+	var result []*types.Type
+	add := func(ts ...*types.Type) {
+		for _, c := range ts {
+			if c != nil {
+				result = append(result, c)
+			}
+		}
+	}
+	add(t.Key, t.Elem, t.Underlying)
+	for _, m := range t.Members {
+		add(m.Type)
+	}
+	add(t.EmbeddedInterfaces...)
+	add(t.OrderedMethods()...)
+	if s := t.Signature; s != nil {
+		add(s.Parameters...)
+		add(s.Results...)
+	}
+	return result
+}
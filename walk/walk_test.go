@@ -0,0 +1,96 @@
+package walk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zhaolion/gen/parser/parsertest"
+	"github.com/zhaolion/gen/types"
+)
+
+func TestWalk(t *testing.T) {
+	u := types.Universe{}
+	node := u.Type(types.Name{Package: "pkg", Name: "Node"})
+	nodePtr := u.Type(types.Name{Name: "*pkg.Node"})
+	nodePtr.Kind, nodePtr.Elem = types.Pointer, node
+	node.Kind = types.Struct
+	node.Members = []types.Member{{Name: "Next", Type: nodePtr}, {Name: "Value", Type: types.String}}
+
+	var pre, post []string
+	structs := 0
+	Walk(node, Funcs{
+		PreAny:   func(t *types.Type) bool { pre = append(pre, t.String()); return true },
+		PostAny:  func(t *types.Type) { post = append(post, t.String()) },
+		PostKind: map[types.Kind]func(*types.Type){types.Struct: func(*types.Type) { structs++ }},
+	})
+	assert.Equal(t, []string{"pkg.Node", "*pkg.Node", "string"}, pre)
+	assert.Equal(t, []string{"*pkg.Node", "string", "pkg.Node"}, post)
+	assert.Equal(t, 1, structs)
+
+	pre = nil
+	Walk(node, Funcs{
+		PreKind: map[types.Kind]func(*types.Type) bool{types.Pointer: func(*types.Type) bool { return false }},
+		PostAny: func(t *types.Type) { pre = append(pre, t.String()) },
+	})
+	assert.Equal(t, []string{"string", "pkg.Node"}, pre)
+}
+
+func TestWalkUniverse(t *testing.T) {
+	universe := parsertest.Universe(t)
+
+	seen := map[*types.Type]int{}
+	Universe(universe, Funcs{PreAny: func(t *types.Type) bool { seen[t]++; return true }})
+	for typ, n := range seen {
+		assert.Equal(t, 1, n, typ.String())
+	}
+	assert.Contains(t, seen, universe.Package("github.com/zhaolion/gen/parser/testpkg/model").Type("Foo"))
+	assert.Contains(t, seen, types.String)
+}
+
+func TestFilter(t *testing.T) {
+	universe := parsertest.Universe(t)
+
+	for expr, want := range map[string][]string{
+		"package=github.com/zhaolion/gen/parser/testpkg/... kind=interface": {
+			"github.com/zhaolion/gen/parser/testpkg/a1.Service",
+			"github.com/zhaolion/gen/parser/testpkg/model.Describer",
+			"github.com/zhaolion/gen/parser/testpkg/model.Namer",
+		},
+		"package=github.com/zhaolion/gen/parser/testpkg/a? name=E*": {
+			"github.com/zhaolion/gen/parser/testpkg/a1.Entry",
+			"github.com/zhaolion/gen/parser/testpkg/a2.Embedded",
+			"github.com/zhaolion/gen/parser/testpkg/a2.Entry",
+		},
+		"package=github.com/zhaolion/gen/parser/testpkg/a2 !name=Entry": {
+			"github.com/zhaolion/gen/parser/testpkg/a2.Embedded",
+		},
+		"tag=json,yaml": {
+			"github.com/zhaolion/gen/parser/testpkg/model.Foo",
+		},
+		"package=github.com/zhaolion/gen/parser/testpkg/model kind=Alias,Struct !tag=json": {
+			"github.com/zhaolion/gen/parser/testpkg/model.Bar",
+			"github.com/zhaolion/gen/parser/testpkg/model.Color",
+		},
+	} {
+		f, err := ParseFilter(expr)
+		if !assert.NoError(t, err, expr) {
+			continue
+		}
+		var got []string
+		for _, typ := range Select(universe, f) {
+			got = append(got, typ.String())
+		}
+		assert.Equal(t, want, got, expr)
+	}
+
+	typ := &types.Type{Name: types.Name{Package: "pkg", Name: "Foo"}, CommentLines: []string{"Foo is foo", "@gen skip"}}
+	f, _ := ParseFilter("marker=gen")
+	assert.True(t, f.Match(typ))
+	f, _ = ParseFilter("!marker=gen")
+	assert.False(t, f.Match(typ))
+
+	for _, expr := range []string{"kind", "=x", "color=red", "name=", "name=[", "name=a,"} {
+		_, err := ParseFilter(expr)
+		assert.Error(t, err, expr)
+	}
+}